@@ -0,0 +1,253 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package proxy
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	options "github.com/talos-systems/protoc-gen-proxy/proxy"
+)
+
+// Fields of the node metadata.
+const (
+	// hostnameField is set to the target.
+	hostnameField = "hostname"
+	// errorField and codeField are set to the error of a failed target
+	// with inline errors.
+	errorField = "error"
+	codeField  = "code"
+)
+
+// aggregation describes the shape of the messages the responses of the
+// targets get merged into.
+type aggregation struct {
+	// list is the repeated field of the response collecting the messages of
+	// each target, nil for streams as each message comes from a single
+	// target.
+	list *protogen.Field
+	// metadata is the field of the collected messages holding the node
+	// metadata.
+	metadata *protogen.Field
+	// hostname is the field of the node metadata set to the target.
+	hostname *protogen.Field
+	// inlineErrors reports the failed targets as messages of the list, with
+	// the error and the code fields of the node metadata set. The code field
+	// is optional.
+	inlineErrors bool
+	errorMessage *protogen.Field
+	code         *protogen.Field
+}
+
+// resolveAggregations checks the responses of all the fanned out methods can
+// be aggregated before anything gets generated.
+func (g *proxy) resolveAggregations(files []*protogen.File) error {
+	for _, f := range files {
+		for _, service := range f.Services {
+			for _, method := range service.Methods {
+				if method.Desc.IsStreamingClient() || methodMode(service, method) != options.Mode_FANOUT {
+					continue
+				}
+
+				agg, err := resolveAggregation(service, method)
+				if err != nil {
+					return err
+				}
+
+				g.aggregations[method] = agg
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveAggregation inspects the output of a fanned out method to find
+// where the responses of the targets go. Unary responses need a repeated
+// field collecting the messages of each target and a node metadata field in
+// these messages. Server streams merge the messages as they come, the ones
+// without a node metadata field are forwarded untagged.
+func resolveAggregation(service *protogen.Service, method *protogen.Method) (*aggregation, error) {
+	opts := methodOptions(service, method).GetAggregation()
+
+	var (
+		agg aggregation
+		err error
+	)
+
+	message := method.Output
+
+	if !method.Desc.IsStreamingServer() {
+		if agg.list, err = listField(message, opts.GetField()); err != nil {
+			return nil, fmt.Errorf("method %s: %w", method.Desc.FullName(), err)
+		}
+
+		message = agg.list.Message
+	}
+
+	if agg.metadata, err = metadataField(message, opts.GetMetadataField()); err != nil {
+		return nil, fmt.Errorf("method %s: %w", method.Desc.FullName(), err)
+	}
+
+	if agg.metadata == nil {
+		if agg.list != nil {
+			return nil, fmt.Errorf("method %s: %s has no node metadata field, set the aggregation metadata_field option to pick one", method.Desc.FullName(), message.Desc.FullName())
+		}
+
+		return &agg, nil
+	}
+
+	agg.hostname = findField(agg.metadata.Message, hostnameField)
+
+	if agg.list != nil && opts.GetInlineErrors() {
+		if err = agg.resolveErrorFields(); err != nil {
+			return nil, fmt.Errorf("method %s: %w", method.Desc.FullName(), err)
+		}
+	}
+
+	return &agg, nil
+}
+
+// resolveErrorFields finds the fields of the node metadata reporting the error
+// of a failed target.
+func (agg *aggregation) resolveErrorFields() error {
+	metadata := agg.metadata.Message
+
+	agg.inlineErrors = true
+
+	agg.errorMessage = findField(metadata, errorField)
+	if agg.errorMessage == nil || agg.errorMessage.Desc.IsList() || agg.errorMessage.Desc.Kind() != protoreflect.StringKind {
+		return fmt.Errorf("inline errors need a string %s field in %s", errorField, metadata.Desc.FullName())
+	}
+
+	agg.code = findField(metadata, codeField)
+	if agg.code == nil {
+		return nil
+	}
+
+	switch agg.code.Desc.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Uint32Kind, protoreflect.EnumKind:
+		if !agg.code.Desc.IsList() {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("field %s of %s isn't an integer or an enum", codeField, metadata.Desc.FullName())
+}
+
+// listField returns the repeated message field of the message named by the
+// options, or the only one the message has.
+func listField(message *protogen.Message, name string) (*protogen.Field, error) {
+	if name != "" {
+		field := findField(message, name)
+
+		switch {
+		case field == nil:
+			return nil, fmt.Errorf("%s has no field %q", message.Desc.FullName(), name)
+		case !field.Desc.IsList() || field.Message == nil:
+			return nil, fmt.Errorf("field %q of %s isn't a repeated message field", name, message.Desc.FullName())
+		}
+
+		return field, nil
+	}
+
+	var candidates []*protogen.Field
+
+	for _, field := range message.Fields {
+		if field.Desc.IsList() && field.Message != nil {
+			candidates = append(candidates, field)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("%s has no repeated message field to collect the responses of the targets, route the method with another mode than FANOUT", message.Desc.FullName())
+	case 1:
+		return candidates[0], nil
+	default:
+		return nil, fmt.Errorf("%s has several repeated message fields, set the aggregation field option to pick one", message.Desc.FullName())
+	}
+}
+
+// metadataField returns the node metadata field of the message named by the
+// options, or the only one the message has. Node metadata messages are the
+// ones with a string hostname field.
+func metadataField(message *protogen.Message, name string) (*protogen.Field, error) {
+	if name != "" {
+		field := findField(message, name)
+
+		switch {
+		case field == nil:
+			return nil, fmt.Errorf("%s has no field %q", message.Desc.FullName(), name)
+		case !isNodeMetadata(field):
+			return nil, fmt.Errorf("field %q of %s isn't a message with a string %s field", name, message.Desc.FullName(), hostnameField)
+		}
+
+		return field, nil
+	}
+
+	var candidates []*protogen.Field
+
+	for _, field := range message.Fields {
+		if isNodeMetadata(field) {
+			candidates = append(candidates, field)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, nil
+	case 1:
+		return candidates[0], nil
+	default:
+		return nil, fmt.Errorf("%s has several node metadata fields, set the aggregation metadata_field option to pick one", message.Desc.FullName())
+	}
+}
+
+func isNodeMetadata(field *protogen.Field) bool {
+	if field.Desc.IsList() || field.Desc.IsMap() || field.Message == nil {
+		return false
+	}
+
+	hostname := findField(field.Message, hostnameField)
+
+	return hostname != nil && !hostname.Desc.IsList() && hostname.Desc.Kind() == protoreflect.StringKind
+}
+
+// tagMetadata returns the statements setting the node metadata of msg to the
+// target.
+func (g *proxy) tagMetadata(agg *aggregation, msg, target string) string {
+	metadata := msg + "." + agg.metadata.GoName
+
+	return "if " + metadata + " == nil {\n" +
+		metadata + " = &" + g.gen.QualifiedGoIdent(agg.metadata.Message.GoIdent) + "{}\n" +
+		"}\n" +
+		metadata + "." + agg.hostname.GoName + " = " + target
+}
+
+// errorEntry returns the expression of the message reporting the error of a
+// failed target with inline errors.
+func (g *proxy) errorEntry(agg *aggregation, target, err string) string {
+	metadata := "&" + g.gen.QualifiedGoIdent(agg.metadata.Message.GoIdent) + "{\n" +
+		agg.hostname.GoName + ": " + target + ",\n" +
+		agg.errorMessage.GoName + ": " + g.gen.QualifiedGoIdent(statusPackage.Ident("Convert")) + "(" + err + ").Message(),\n"
+
+	if agg.code != nil {
+		codeType := agg.code.Desc.Kind().String()
+		if agg.code.Enum != nil {
+			codeType = g.gen.QualifiedGoIdent(agg.code.Enum.GoIdent)
+		}
+
+		metadata += agg.code.GoName + ": " + codeType + "(" + g.gen.QualifiedGoIdent(statusPackage.Ident("Code")) + "(" + err + ")),\n"
+	}
+
+	metadata += "}"
+
+	return "&" + g.gen.QualifiedGoIdent(agg.list.Message.GoIdent) + "{\n" +
+		agg.metadata.GoName + ": " + metadata + ",\n" +
+		"}"
+}
@@ -0,0 +1,513 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package proxy_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/logs"
+	"github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/modes"
+	"github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/routing"
+	"github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/streaming"
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime/runtimetest"
+)
+
+type routingNode struct {
+	routing.UnimplementedRoutingServer
+
+	name string
+}
+
+func (n *routingNode) Fanout(context.Context, *emptypb.Empty) (*routing.FanoutResponse, error) {
+	return &routing.FanoutResponse{Messages: []*routing.Reply{{Message: n.name}}}, nil
+}
+
+func (n *routingNode) Partial(context.Context, *emptypb.Empty) (*routing.FanoutResponse, error) {
+	if n.name == "node-2" {
+		return nil, status.Error(codes.Unavailable, "disk is full")
+	}
+
+	return &routing.FanoutResponse{Messages: []*routing.Reply{{Message: n.name}}}, nil
+}
+
+func (n *routingNode) Majority(context.Context, *emptypb.Empty) (*routing.FanoutResponse, error) {
+	return &routing.FanoutResponse{Messages: []*routing.Reply{{Message: n.name}}}, nil
+}
+
+// Stats fails like a target running a proxy which doesn't route the method.
+func (n *routingNode) Stats(context.Context, *emptypb.Empty) (*routing.StatsResponse, error) {
+	return nil, runtime.ErrUnknownMethod
+}
+
+type logsNode struct {
+	logs.UnimplementedLogsServer
+
+	name string
+}
+
+func (n *logsNode) Tail(req *logs.TailRequest, srv grpc.ServerStreamingServer[logs.LogEntry]) error {
+	for i := range 2 {
+		if err := srv.Send(&logs.LogEntry{Line: []byte(fmt.Sprintf("%s %s %d", n.name, req.Source, i))}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// serve starts a server on the in-memory listener of target, the services
+// get registered by register.
+func serve(t *testing.T, dialer *runtimetest.BufconnDialer, target string, register func(*grpc.Server), opts ...grpc.ServerOption) {
+	t.Helper()
+
+	s := grpc.NewServer(opts...)
+	register(s)
+
+	go s.Serve(dialer.Listen(target)) //nolint:errcheck
+
+	t.Cleanup(s.Stop)
+}
+
+// nodes are the targets of the requests, node-i listening on the i-th one.
+var nodes = []string{"10.5.0.2:50000", "10.5.0.3:50000", "10.5.0.4:50000"}
+
+func targetsContext(targets ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.MD{"targets": targets})
+}
+
+// certificateProvider hands out a self-signed certificate, valid for the
+// addresses of the nodes, both to the proxy and to the nodes.
+type certificateProvider struct {
+	ca   []byte
+	cert tls.Certificate
+}
+
+func newCertificateProvider(t *testing.T) *certificateProvider {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "node"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+
+	for _, node := range nodes {
+		host, _, _ := net.SplitHostPort(node) //nolint:errcheck
+
+		template.IPAddresses = append(template.IPAddresses, net.ParseIP(host))
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &certificateProvider{
+		ca:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		cert: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+	}
+}
+
+func (p *certificateProvider) GetCA() ([]byte, error) {
+	return p.ca, nil
+}
+
+func (p *certificateProvider) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return &p.cert, nil
+}
+
+// serverCredentials are the credentials of the nodes.
+func (p *certificateProvider) serverCredentials() grpc.ServerOption {
+	return grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{p.cert}}))
+}
+
+// mutualCredentials are the credentials of a proxy node requiring the
+// callers to present a certificate.
+func (p *certificateProvider) mutualCredentials() grpc.ServerOption {
+	return grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{p.cert},
+		ClientAuth:   tls.RequireAnyClientCert,
+	}))
+}
+
+// clientCredentials present the certificate, which holds no role, to the
+// proxy node.
+func (p *certificateProvider) clientCredentials() credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		Certificates:       []tls.Certificate{p.cert},
+		InsecureSkipVerify: true, //nolint:gosec
+	})
+}
+
+// newModesProxy starts the nodes serving the routing service, the proxy
+// dials them with the credentials of provider.
+func newModesProxy(t *testing.T, provider runtime.CertificateProvider, opts ...grpc.ServerOption) *modes.ModesProxy {
+	t.Helper()
+
+	dialer := runtimetest.NewBufconnDialer()
+
+	for i, target := range nodes {
+		node := &routingNode{name: fmt.Sprintf("node-%d", i+1)}
+
+		serve(t, dialer, target, func(s *grpc.Server) { routing.RegisterRoutingServer(s, node) }, opts...)
+	}
+
+	p := modes.NewModesProxy(provider)
+	p.Dialer = dialer
+
+	t.Cleanup(func() { p.Close() }) //nolint:errcheck
+
+	return p
+}
+
+// serveProxy starts the proxy node, serving the routing service behind the
+// interceptors of p, and returns a connection to it made with creds.
+func serveProxy(t *testing.T, p *modes.ModesProxy, creds credentials.TransportCredentials, opts ...grpc.ServerOption) *grpc.ClientConn {
+	t.Helper()
+
+	dialer := p.Dialer.(*runtimetest.BufconnDialer) //nolint:forcetypeassert
+
+	opts = append(opts, grpc.UnaryInterceptor(p.UnaryInterceptor()), grpc.StreamInterceptor(p.StreamInterceptor()))
+
+	serve(t, dialer, "proxy", func(s *grpc.Server) { routing.RegisterRoutingServer(s, &routingNode{name: "proxy"}) }, opts...)
+
+	conn, err := dialer.Dial("proxy", creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { conn.Close() }) //nolint:errcheck
+
+	return conn
+}
+
+func replies(resp *routing.FanoutResponse) []string {
+	var out []string
+
+	for _, msg := range resp.Messages {
+		if msg.Message == "" {
+			out = append(out, msg.Metadata.GetHostname()+": "+msg.Metadata.GetError())
+
+			continue
+		}
+
+		out = append(out, msg.Metadata.GetHostname()+": "+msg.Message)
+	}
+
+	sort.Strings(out)
+
+	return out
+}
+
+func TestUnaryProxyFanout(t *testing.T) {
+	p := newModesProxy(t, nil)
+
+	resp, err := p.UnaryProxy(targetsContext(nodes...), "/routing.Routing/Fanout", insecure.NewCredentials(), &emptypb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"10.5.0.2:50000: node-1", "10.5.0.3:50000: node-2", "10.5.0.4:50000: node-3"}
+
+	if got := replies(resp.(*routing.FanoutResponse)); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected replies %v", got)
+	}
+}
+
+func TestUnaryProxyUnreachable(t *testing.T) {
+	p := newModesProxy(t, nil)
+
+	resp, err := p.UnaryProxy(targetsContext(nodes[0], "10.5.0.9:50000"), "/routing.Routing/Fanout", insecure.NewCredentials(), &emptypb.Empty{})
+
+	targetErrs := runtime.TargetErrors(err)
+	if len(targetErrs) != 1 || targetErrs[0].Target != "10.5.0.9:50000" || status.Code(targetErrs[0].Err) != codes.Unavailable {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// the reachable target still answers
+	if got := replies(resp.(*routing.FanoutResponse)); !reflect.DeepEqual(got, []string{"10.5.0.2:50000: node-1"}) {
+		t.Errorf("unexpected replies %v", got)
+	}
+}
+
+func TestUnaryProxyNoTargets(t *testing.T) {
+	p := newModesProxy(t, nil)
+	p.Resolver = &runtime.StaticResolver{}
+
+	// a caller allowed to call Single
+	ctx := peer.NewContext(targetsContext(runtime.TargetAll), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+			{Subject: pkix.Name{CommonName: "admin", Organization: []string{"os:admin"}}},
+		}}},
+	})
+
+	resp, err := p.UnaryProxy(ctx, "/routing.Routing/Single", insecure.NewCredentials(), &emptypb.Empty{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unexpected outcome %v, %v", resp, err)
+	}
+
+	// without the targets metadata the proxy doesn't fall back to its own authority
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(":authority", nodes[0]))
+
+	resp, err = p.UnaryProxy(ctx, "/routing.Routing/Fanout", insecure.NewCredentials(), &emptypb.Empty{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unexpected outcome %v, %v", resp, err)
+	}
+}
+
+func TestUnaryProxyInlineErrors(t *testing.T) {
+	p := newModesProxy(t, nil)
+
+	resp, err := p.UnaryProxy(targetsContext(nodes[:2]...), "/routing.Routing/Partial", insecure.NewCredentials(), &emptypb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"10.5.0.2:50000: node-1", "10.5.0.3:50000: disk is full"}
+
+	if got := replies(resp.(*routing.FanoutResponse)); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected replies %v", got)
+	}
+}
+
+func TestUnaryProxyInlineErrorsQuorum(t *testing.T) {
+	p := newModesProxy(t, nil)
+
+	// 3 out of 4 targets make the majority, the unreachable one isn't needed
+	resp, err := p.UnaryProxy(targetsContext(append(slices.Clone(nodes), "10.5.0.9:50000")...), "/routing.Routing/Majority", insecure.NewCredentials(), &emptypb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := replies(resp.(*routing.FanoutResponse)); len(got) != 3 {
+		t.Errorf("unexpected replies %v", got)
+	}
+
+	// 1 out of 4 doesn't, the call fails rather than listing the failed targets
+	for name, unreachable := range map[string][]string{
+		"failed":     {"10.5.0.9:50000", "10.5.0.10:50000", "10.5.0.11:50000"},
+		"not dialed": {"10.5.0.9:0", "10.5.0.10:0", "10.5.0.11:0"},
+	} {
+		resp, err = p.UnaryProxy(targetsContext(append([]string{nodes[0]}, unreachable...)...), "/routing.Routing/Majority", insecure.NewCredentials(), &emptypb.Empty{})
+		if err == nil || resp != nil {
+			t.Errorf("%s: unexpected outcome %v, %v", name, resp, err)
+		}
+
+		if len(runtime.TargetErrors(err)) == 0 {
+			t.Errorf("%s: the errors of the targets are missing: %v", name, err)
+		}
+	}
+}
+
+func TestStreamProxyMerge(t *testing.T) {
+	dialer := runtimetest.NewBufconnDialer()
+
+	for i, target := range nodes {
+		node := &logsNode{name: fmt.Sprintf("node-%d", i+1)}
+
+		serve(t, dialer, target, func(s *grpc.Server) { logs.RegisterLogsServer(s, node) })
+	}
+
+	p := streaming.NewStreamingProxy(nil)
+	p.Dialer = dialer
+
+	defer p.Close() //nolint:errcheck
+
+	// the proxy serves every method it gets called with
+	serve(t, dialer, "proxy", func(*grpc.Server) {}, grpc.UnknownServiceHandler(func(srv interface{}, ss grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(ss)
+
+		return p.StreamProxy(ss, method, insecure.NewCredentials(), srv)
+	}))
+
+	conn, err := dialer.Dial("proxy", insecure.NewCredentials())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close() //nolint:errcheck
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "targets", nodes[0], "targets", nodes[2])
+
+	stream, err := logs.NewLogsClient(conn).Tail(ctx, &logs.TailRequest{Source: "kubelet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+
+	for {
+		entry, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines = append(lines, entry.Metadata.GetHostname()+": "+string(entry.Line))
+	}
+
+	sort.Strings(lines)
+
+	expected := []string{
+		"10.5.0.2:50000: node-1 kubelet 0",
+		"10.5.0.2:50000: node-1 kubelet 1",
+		"10.5.0.4:50000: node-3 kubelet 0",
+		"10.5.0.4:50000: node-3 kubelet 1",
+	}
+
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("unexpected lines %v", lines)
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	provider := newCertificateProvider(t)
+
+	p := newModesProxy(t, provider, provider.serverCredentials())
+	p.VerifyTargetName = true
+
+	resps, errs := p.FanOutFanout(context.Background(), []string{nodes[0], nodes[1], "10.5.0.9:50000"}, &emptypb.Empty{})
+
+	if len(resps) != 2 {
+		t.Fatalf("unexpected responses %v", resps)
+	}
+
+	for target, name := range map[string]string{nodes[0]: "node-1", nodes[1]: "node-2"} {
+		reply := resps[target].GetMessages()[0]
+
+		if reply.Message != name || reply.Metadata.GetHostname() != target {
+			t.Errorf("unexpected reply from %s: %v", target, reply)
+		}
+	}
+
+	if len(errs) != 1 || status.Code(errs["10.5.0.9:50000"]) != codes.Unavailable {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
+
+func TestFanOutPolicy(t *testing.T) {
+	provider := newCertificateProvider(t)
+
+	p := newModesProxy(t, provider, provider.serverCredentials())
+	p.TargetPolicy = &runtime.TargetPolicy{MaxTargets: 2}
+
+	resps, errs := p.FanOutStats(context.Background(), nodes, &emptypb.Empty{})
+
+	if len(resps) != 0 || len(errs) != len(nodes) {
+		t.Fatalf("unexpected outcome %v, %v", resps, errs)
+	}
+
+	for _, err := range errs {
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+
+	// the rejection of a call without targets isn't lost
+	p.TargetPolicy.Authorize = func(context.Context, string, []string) error {
+		return status.Error(codes.PermissionDenied, "no target")
+	}
+
+	if _, errs = p.FanOutStats(context.Background(), nil, &emptypb.Empty{}); status.Code(errs[""]) != codes.PermissionDenied {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
+
+func TestFallbackUnknownOnTarget(t *testing.T) {
+	provider := newCertificateProvider(t)
+
+	p := newModesProxy(t, provider, provider.serverCredentials())
+
+	fallbacks := 0
+
+	p.UnaryFallback = func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		fallbacks++
+
+		return handler(ctx, req)
+	}
+
+	client := routing.NewRoutingClient(serveProxy(t, p, insecure.NewCredentials()))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "targets", nodes[0])
+
+	// the target doesn't route the method, the proxy does
+	if _, err := client.Stats(ctx, &emptypb.Empty{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// the skipped methods are left to the fallback
+	if _, err := client.Skipped(ctx, &emptypb.Empty{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if fallbacks != 1 {
+		t.Errorf("fallback called %d times", fallbacks)
+	}
+}
+
+func TestInterceptorRolesSpoofedRoute(t *testing.T) {
+	provider := newCertificateProvider(t)
+
+	p := newModesProxy(t, provider, provider.serverCredentials())
+
+	client := routing.NewRoutingClient(serveProxy(t, p, provider.clientCredentials(), provider.mutualCredentials()))
+
+	// the caller pretends the request went through a proxy already, for the
+	// proxy node to handle it
+	ctx := metadata.AppendToOutgoingContext(context.Background(), runtime.MetadataVia, "gateway")
+
+	if _, err := client.Single(ctx, &emptypb.Empty{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// the methods without roles are still handled locally
+	resp, err := client.Fanout(ctx, &emptypb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := resp.GetMessages()[0].GetMessage(); got != "proxy" {
+		t.Errorf("unexpected reply %q", got)
+	}
+}
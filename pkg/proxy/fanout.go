@@ -0,0 +1,113 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package proxy
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+
+	options "github.com/talos-systems/protoc-gen-proxy/proxy"
+)
+
+// generateFanOutMethod generates the typed FanOut<Method> method of the
+// proxy, letting the Go code of the process fan a call out without going
+// through the interceptors. The call is dispatched like the proxied requests,
+// with the same clients and runner, and the outcome is keyed by target. As the
+// process itself is the caller, the roles and the Authorizer aren't checked.
+func (g *proxy) generateFanOutMethod(service *protogen.Service, method *protogen.Method) {
+	if method.Desc.IsStreamingServer() || method.Desc.IsStreamingClient() {
+		return
+	}
+
+	if methodMode(service, method) != options.Mode_FANOUT {
+		return
+	}
+
+	output := g.typeName(method.Output)
+
+	g.P(g.FanOut, "// FanOut"+method.GoName+" calls "+method.GoName+" on the targets the way the proxied")
+	g.P(g.FanOut, "// requests are, it returns the responses and the errors keyed by target.")
+	g.P(g.FanOut, "// The errors which aren't tied to a target, e.g. without any target, are")
+	g.P(g.FanOut, "// keyed by an empty target. The roles of the method and the Authorizer")
+	g.P(g.FanOut, "// aren't checked, the calls being made by the process itself.")
+	g.P(g.FanOut, "func (p *"+g.proxyName()+") FanOut"+method.GoName+"(",
+		"ctx ", contextPackage.Ident("Context"), ", ",
+		"targets []string, ",
+		"in *"+g.typeName(method.Input)+", ",
+		"opts ...", grpcPackage.Ident("CallOption"),
+		") (map[string]*"+output+", map[string]error) {")
+	g.P(g.FanOut, "resolved, creds, proxyMd, err := p.fanOutTargets(ctx, \""+fullMethodName(service, method)+"\", targets)")
+	g.P(g.FanOut, "if err != nil {")
+	g.P(g.FanOut, "return nil, ", runtimePackage.Ident("ErrorForTargets"), "(targets, err)")
+	g.P(g.FanOut, "}")
+	g.P(g.FanOut, "execution := "+g.execution(service, method))
+	g.generateCallOptions(g.FanOut, service, method)
+	g.P(g.FanOut, "clients, release, dialErr := p.create"+service.GoName+"Client(ctx, resolved, creds, proxyMd, opts)")
+	g.P(g.FanOut, "defer release()")
+	g.P(g.FanOut, "clientTargets := make([]string, len(clients))")
+	g.P(g.FanOut, "for i, client := range clients {")
+	g.P(g.FanOut, "clientTargets[i] = client.Target")
+	g.P(g.FanOut, "}")
+
+	// the targets which couldn't be dialed count against the dispatch
+	if methodOptions(service, method).GetExecution().GetDispatch() != options.Dispatch_ALL {
+		g.P(g.FanOut, "execution.Unreachable = len(", runtimePackage.Ident("TargetErrors"), "(dialErr))")
+	}
+
+	g.P(g.FanOut, "msgs, errs := execution.RunTargets(", metadataPackage.Ident("NewOutgoingContext"), "(ctx, proxyMd), clientTargets, func(ctx ", contextPackage.Ident("Context"), ", i int) (", protoPackage.Ident("Message"), ", error) {")
+	g.P(g.FanOut, "return proxy"+method.GoName+"(ctx, clients[i], in)")
+	g.P(g.FanOut, "})")
+	g.P(g.FanOut, "// the targets which couldn't be dialed failed as well")
+	g.P(g.FanOut, "for _, targetErr := range ", runtimePackage.Ident("TargetErrors"), "(dialErr) {")
+	g.P(g.FanOut, "errs[targetErr.Target] = targetErr.Err")
+	g.P(g.FanOut, "}")
+	g.P(g.FanOut, "resps := make(map[string]*"+output+", len(msgs))")
+	g.P(g.FanOut, "for target, msg := range msgs {")
+	g.P(g.FanOut, "resps[target] = msg.(*"+output+")")
+	g.P(g.FanOut, "}")
+	g.P(g.FanOut, "return resps, errs")
+	g.P(g.FanOut, "}")
+	g.P(g.FanOut, "")
+}
+
+// generateFanOutTargets generates the helper preparing the calls of the
+// FanOut methods: the targets are resolved and checked against the target
+// policy like the proxied requests, there is no caller to check the roles
+// of though. The route of the incoming request, if any, is passed on so the
+// targets don't proxy the calls any further.
+func (g *proxy) generateFanOutTargets() {
+	if g.FanOut.Len() == 0 {
+		return
+	}
+
+	g.gen.P("// fanOutTargets returns the targets of a call made through the FanOut")
+	g.gen.P("// methods, along with the credentials to dial them with and the metadata")
+	g.gen.P("// to send them.")
+	g.gen.P("func (p *"+g.proxyName()+") fanOutTargets(",
+		"ctx ", contextPackage.Ident("Context"), ", ",
+		"method string, ",
+		"targets []string",
+		") ([]string, ", credentialsPackage.Ident("TransportCredentials"), ", ", metadataPackage.Ident("MD"), ", error) {")
+	g.gen.P("var err error")
+	g.gen.P("if p.Resolver != nil {")
+	g.gen.P("if targets, err = p.Resolver.Resolve(ctx, targets); err != nil {")
+	g.gen.P("return nil, nil, nil, err")
+	g.gen.P("}")
+	g.gen.P("}")
+	g.gen.P("if err = p.TargetPolicy.Check(ctx, method, targets); err != nil {")
+	g.gen.P("return nil, nil, nil, err")
+	g.gen.P("}")
+	g.gen.P("creds, err := p.Credentials.Get(p.Provider)")
+	g.gen.P("if err != nil {")
+	g.gen.P("return nil, nil, nil, err")
+	g.gen.P("}")
+	g.gen.P("md, _ := ", metadataPackage.Ident("FromIncomingContext"), "(ctx)")
+	g.gen.P("proxyMd := ", runtimePackage.Ident("ForwardMetadata"), "(md, p.ForwardedMetadata)")
+	g.gen.P(runtimePackage.Ident("SetVia"), "(proxyMd, md, p.Name)")
+	g.gen.P("return targets, creds, proxyMd, nil")
+	g.gen.P("}")
+	g.gen.P("")
+
+	g.gen.P(g.FanOut.String())
+}
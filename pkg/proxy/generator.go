@@ -37,34 +37,72 @@ package proxy
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
-	"strconv"
 	"strings"
 
-	"github.com/golang/protobuf/protoc-gen-go/generator"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
-// generatedCodeVersion indicates a version of the generated code.
-// It is incremented whenever an incompatibility between the generated code and
-// the grpc package is introduced; the generated code references
-// a constant, grpc.SupportPackageIsVersionN (where N is generatedCodeVersion).
-const generatedCodeVersion = 4
-
-// Paths for packages used by code generated in this file,
-// relative to the import_prefix of the generator.Generator.
+// Paths for packages used by code generated in this file.
 const (
-	contextPkgPath = "context"
-	grpcPkgPath    = "google.golang.org/grpc"
-	codePkgPath    = "google.golang.org/grpc/codes"
-	statusPkgPath  = "google.golang.org/grpc/status"
+	contextPackage     = protogen.GoImportPath("context")
+	ioPackage          = protogen.GoImportPath("io")
+	syncPackage        = protogen.GoImportPath("sync")
+	timePackage        = protogen.GoImportPath("time")
+	grpcPackage        = protogen.GoImportPath("google.golang.org/grpc")
+	credentialsPackage = protogen.GoImportPath("google.golang.org/grpc/credentials")
+	metadataPackage    = protogen.GoImportPath("google.golang.org/grpc/metadata")
+	protoPackage       = protogen.GoImportPath("google.golang.org/protobuf/proto")
+	statusPackage      = protogen.GoImportPath("google.golang.org/grpc/status")
+	codesPackage       = protogen.GoImportPath("google.golang.org/grpc/codes")
+	runtimePackage     = protogen.GoImportPath("github.com/talos-systems/protoc-gen-proxy/pkg/runtime")
 )
 
-func init() {
-	generator.RegisterPlugin(new(proxy))
+// Params are the parameters of the plugin, passed with --proxy_opt.
+type Params struct {
+	// DefaultPort is the port dialed when a target doesn't specify one.
+	DefaultPort int
+	// Provider is the type of the certificate provider of the generated
+	// proxy, given as <import path>.<type>. It must satisfy
+	// runtime.CertificateProvider.
+	Provider string
+	// SocketPaths is the import path of a package declaring the
+	// <Service>SocketPath constants used by the local clients. By default
+	// the socket paths are looked up with runtime.SocketPath.
+	SocketPaths string
+}
+
+// Flags returns the flag set binding the plugin parameters to p. It is meant
+// to be used as protogen.Options.ParamFunc.
+func (p *Params) Flags() *flag.FlagSet {
+	flags := flag.NewFlagSet("protoc-gen-proxy", flag.ContinueOnError)
+
+	flags.IntVar(&p.DefaultPort, "default_port", 0, "port dialed when a target doesn't specify one")
+	flags.StringVar(&p.Provider, "provider", "", "type of the certificate provider, as <import path>.<type>")
+	flags.StringVar(&p.SocketPaths, "socket_paths", "", "import path of the package declaring the <Service>SocketPath constants")
+
+	return flags
+}
+
+// providerIdent returns the identifier of the certificate provider type.
+func (p Params) providerIdent() (protogen.GoIdent, error) {
+	if p.Provider == "" {
+		return runtimePackage.Ident("CertificateProvider"), nil
+	}
+
+	idx := strings.LastIndex(p.Provider, ".")
+	if idx <= 0 || idx == len(p.Provider)-1 || strings.HasSuffix(p.Provider[:idx], "/") {
+		return protogen.GoIdent{}, fmt.Errorf("invalid provider %q: expected <import path>.<type>", p.Provider)
+	}
+
+	return protogen.GoImportPath(p.Provider[:idx]).Ident(p.Provider[idx+1:]), nil
 }
 
-// grpc is an implementation of the Go protocol buffer compiler's
-// plugin architecture.  It generates bindings for gRPC support.
+// proxy is an implementation of the Go protocol buffer compiler's
+// plugin architecture. It generates proxy bindings on top of gRPC.
 type proxy struct {
 	ProxySwitch         *bytes.Buffer
 	StreamProxySwitch   *bytes.Buffer
@@ -73,53 +111,83 @@ type proxy struct {
 	Clients             *bytes.Buffer
 	Registrator         *bytes.Buffer
 	RegistratorRegister *bytes.Buffer
+	MethodRoles         *bytes.Buffer
+	StreamRequests      *bytes.Buffer
+	FanOut              *bytes.Buffer
 
 	GrpcClient *bytes.Buffer
 	GrpcServer *bytes.Buffer
 
 	WrapperFns *bytes.Buffer
 
-	gen *generator.Generator
+	// localMethods lists the methods always handled by the local server.
+	localMethods []string
+	// routedMethods lists the methods routed by UnaryProxy and StreamProxy.
+	routedMethods []string
+	// services lists the full names of the proxied services.
+	services []string
+	// aggregations holds the shape of the responses of the fanned out
+	// methods.
+	aggregations map[*protogen.Method]*aggregation
+
+	params Params
+	plugin *protogen.Plugin
+	file   *protogen.File
+	gen    *protogen.GeneratedFile
 }
 
-// Name returns the name of this plugin, "proxy".
-func (g *proxy) Name() string {
-	return "proxy"
-}
+// Generate is the main entrypoint to the plugin. It emits a _proxy.pb.go file
+// for each of the files to generate.
+func Generate(plugin *protogen.Plugin, params Params) error {
+	if _, err := params.providerIdent(); err != nil {
+		return err
+	}
 
-// The names for packages imported in the generated code.
-// They may vary from the final path component of the import path
-// if the name is used by other packages.
-var (
-	contextPkg string
-	grpcPkg    string
-)
+	for _, file := range plugin.Files {
+		if !file.Generate {
+			continue
+		}
+
+		if err := newProxy(plugin, file, params).Generate(); err != nil {
+			return err
+		}
+	}
 
-// Init initializes the plugin.
-func (g *proxy) Init(gen *generator.Generator) {
-	g.gen = gen
-	g.ProxySwitch = new(bytes.Buffer)
-	g.StreamProxySwitch = new(bytes.Buffer)
-	g.ProxyFns = new(bytes.Buffer)
-	g.InitClients = new(bytes.Buffer)
-	g.Clients = new(bytes.Buffer)
-	g.WrapperFns = new(bytes.Buffer)
-	g.Registrator = new(bytes.Buffer)
-	g.RegistratorRegister = new(bytes.Buffer)
-	g.GrpcClient = new(bytes.Buffer)
-	g.GrpcServer = new(bytes.Buffer)
+	return nil
 }
 
-// Given a type name defined in a .proto, return its object.
-// Also record that we're using it, to guarantee the associated import.
-func (g *proxy) objectNamed(name string) generator.Object {
-	g.gen.RecordTypeUse(name)
-	return g.gen.ObjectNamed(name)
+// newProxy initializes the plugin for a single file to generate.
+func newProxy(plugin *protogen.Plugin, file *protogen.File, params Params) *proxy {
+	return &proxy{
+		ProxySwitch:         new(bytes.Buffer),
+		StreamProxySwitch:   new(bytes.Buffer),
+		ProxyFns:            new(bytes.Buffer),
+		InitClients:         new(bytes.Buffer),
+		Clients:             new(bytes.Buffer),
+		WrapperFns:          new(bytes.Buffer),
+		Registrator:         new(bytes.Buffer),
+		RegistratorRegister: new(bytes.Buffer),
+		MethodRoles:         new(bytes.Buffer),
+		StreamRequests:      new(bytes.Buffer),
+		FanOut:              new(bytes.Buffer),
+		GrpcClient:          new(bytes.Buffer),
+		GrpcServer:          new(bytes.Buffer),
+		params:              params,
+		plugin:              plugin,
+		file:                file,
+		aggregations:        make(map[*protogen.Method]*aggregation),
+	}
+}
+
+// typeName returns the name of the message as we will print it, recording
+// the associated import.
+func (g *proxy) typeName(message *protogen.Message) string {
+	return g.gen.QualifiedGoIdent(message.GoIdent)
 }
 
-// Given a type name defined in a .proto, return its name as we will print it.
-func (g *proxy) typeName(str string) string {
-	return g.gen.TypeName(g.objectNamed(str))
+// proxyName returns the name of the public proxy struct.
+func (g *proxy) proxyName() string {
+	return camelCase(string(g.file.Desc.Package().Name()) + "_proxy")
 }
 
 // P writes to internal (*proxy) buffers that later get consumed by
@@ -152,120 +220,174 @@ func (g *proxy) printAtom(w *bytes.Buffer, v interface{}) {
 		fmt.Fprint(w, v)
 	case *float64:
 		fmt.Fprint(w, *v)
-	case generator.GoPackageName:
-		w.WriteString(string(v))
-	case generator.GoImportPath:
-		w.WriteString(strconv.Quote(string(v)))
+	case protogen.GoIdent:
+		w.WriteString(g.gen.QualifiedGoIdent(v))
 	default:
-		g.gen.Fail(fmt.Sprintf("unknown type in printer: %T", v))
+		panic(fmt.Sprintf("unknown type in printer: %T", v))
 	}
 }
 
-// GenerateImports generates the import declaration for this file.
-func (g *proxy) GenerateImports(file *generator.FileDescriptor) {
-}
+// importedFiles returns all the files transitively imported by the file to
+// generate, in the order they were handed over by protoc.
+func (g *proxy) importedFiles() []*protogen.File {
+	seen := make(map[string]struct{})
+
+	var walk func(protoreflect.FileDescriptor)
 
-// Generate is the main entrypoint to the plugin. This is where all
-// the magic happens.
-func (g *proxy) Generate(file *generator.FileDescriptor) {
-	// Try to filter out non-builtins
-	// ex, we don't want to do this for  google/protobuf/empty.proto
-	if strings.Contains(*file.Package, "google") {
-		return
+	walk = func(fd protoreflect.FileDescriptor) {
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			imp := imports.Get(i)
+			if _, ok := seen[imp.Path()]; ok {
+				continue
+			}
+
+			seen[imp.Path()] = struct{}{}
+			walk(imp.FileDescriptor)
+		}
 	}
 
-	// If we're dealing with the actual file to generate,
-	// we'll print out everything we've generated so far ( all stored
-	// bytes.Buffers ) and we'll generate the high level wrappers
-	// like `Proxy()`, `UnaryInterceptor()`, `Runner()`
-	for _, f := range g.gen.Request.FileToGenerate {
-		if file.GetName() == f {
-			g.generate(file)
-			return
+	walk(g.file.Desc)
+
+	files := make([]*protogen.File, 0, len(seen))
+
+	for _, f := range g.plugin.Files {
+		if _, ok := seen[f.Desc.Path()]; ok {
+			files = append(files, f)
 		}
 	}
 
-	// Otherwise, we'll generate all the fun per package/proto
+	return files
+}
+
+// Generate generates the proxy for a single file. The services of all the
+// imported files get routed through the proxy; this is where all the magic
+// happens.
+func (g *proxy) Generate() error {
+	var files []*protogen.File
+
+	for _, f := range g.importedFiles() {
+		// Try to filter out non-builtins
+		// ex, we don't want to do this for  google/protobuf/empty.proto
+		if strings.Contains(string(f.Desc.Package()), "google") {
+			continue
+		}
+
+		if len(f.Services) == 0 {
+			continue
+		}
+
+		files = append(files, f)
+	}
+
+	// Nothing to proxy
+	if len(files) == 0 {
+		return nil
+	}
+
+	if err := g.resolveAggregations(files); err != nil {
+		return err
+	}
+
+	g.gen = g.plugin.NewGeneratedFile(g.file.GeneratedFilenamePrefix+"_proxy.pb.go", g.file.GoImportPath)
+
+	// We'll generate all the fun per package/proto
 	// imports and switch statements so we can satisfy the
 	// - switch statement cases
 	// - various function definitions
 	// - client creation functions
-	for _, service := range file.FileDescriptorProto.Service {
-		serviceName := generator.CamelCase(service.GetName())
-
-		// g.ProxySwitch
-		g.generateUnarySwitchStatement(serviceName, file.GetPackage(), service.Method)
-		// g.StreamProxySwitch
-		g.generateStreamSwitchStatement(serviceName, file.GetPackage(), service.Method)
-
-		// g.ProxyFns
-		g.generateServiceFuncType(serviceName)
-		g.generateServiceRunner(serviceName)
-		g.generateProxyClientStruct(serviceName, file.GetPackage())
-
-		for _, method := range service.Method {
-			// No support for streaming stuff yet
-			// TODO when we look at multi stream support
-			if method.GetServerStreaming() || method.GetClientStreaming() {
-				continue
-			}
+	for _, f := range files {
+		for _, service := range f.Services {
+			g.services = append(g.services, string(service.Desc.FullName()))
+
+			// g.ProxySwitch
+			g.generateUnarySwitchStatement(service)
+			// g.StreamProxySwitch
+			g.generateStreamSwitchStatement(service)
 
 			// g.ProxyFns
-			g.generateServiceFunc(serviceName, method)
-		}
+			g.generateServiceFuncType(service)
+			g.generateServiceRunner(service)
+			g.generateProxyClientStruct(service)
+
+			for _, method := range service.Methods {
+				// Only the unary methods get runner funcs and FanOut
+				// methods, the streams are proxied by StreamProxy
+				if method.Desc.IsStreamingServer() || method.Desc.IsStreamingClient() {
+					continue
+				}
+
+				// g.ProxyFns
+				g.generateServiceFunc(service, method)
+
+				// g.FanOut
+				g.generateFanOutMethod(service, method)
+			}
 
-		// g.Clients
-		g.generateClientFns(serviceName, file.GetPackage())
+			// g.Clients
+			g.generateClientFns(service)
 
-		// g.Registrator
-		g.generateRegistrator(service, file.GetPackage())
+			// g.Registrator
+			g.generateRegistrator(service)
 
-		// g.RegistratorRegister
-		g.generateRegistratorRegister(service, file.GetPackage())
+			// g.RegistratorRegister
+			g.generateRegistratorRegister(service)
 
-		for _, method := range service.Method {
-			// Need to generate a full set of methods to satisfy
-			// the server interface
+			for _, method := range service.Methods {
+				// Need to generate a full set of methods to satisfy
+				// the server interface
 
-			// g.GrpcServer
-			g.generateServerMethods(serviceName, file.GetPackage(), method)
-		}
+				// g.GrpcServer
+				g.generateServerMethods(service, method)
+			}
 
-		// g.GrpcClient
-		g.generateLocalClient(serviceName, file.GetPackage())
+			// g.GrpcClient
+			g.generateLocalClient(service)
 
-		for _, method := range service.Method {
-			// Need to generate a full set of methods to satisfy
-			// the client interface
+			for _, method := range service.Methods {
+				// Need to generate a full set of methods to satisfy
+				// the client interface
 
-			// g.GrpcClient
-			g.generateClientMethods(serviceName, file.GetPackage(), method)
+				// g.GrpcClient
+				g.generateClientMethods(service, method)
+			}
 		}
 	}
+
+	g.generate()
+
+	return nil
 }
 
-func (g *proxy) generate(file *generator.FileDescriptor) {
-	g.gen.AddImport("io")
-	g.gen.AddImport("sync")
-	g.gen.AddImport("google.golang.org/grpc/metadata")
-	g.gen.AddImport("google.golang.org/grpc/credentials")
-	g.gen.AddImport("github.com/hashicorp/go-multierror")
-	// Support `provider`
-	g.gen.AddImport("github.com/talos-systems/talos/pkg/grpc/tls")
-	// Support for socket paths
-	g.gen.AddImport("github.com/talos-systems/talos/pkg/constants")
+// generate prints out everything we've generated so far ( all stored
+// bytes.Buffers ) along with the high level wrappers like `Proxy()`,
+// `UnaryInterceptor()`, `Runner()`.
+func (g *proxy) generate() {
+	g.gen.P("// Code generated by protoc-gen-proxy. DO NOT EDIT.")
+	g.gen.P("// source: ", g.file.Desc.Path())
+	g.gen.P("")
+	g.gen.P("package ", g.file.GoPackageName)
+	g.gen.P("")
+
+	g.generateProxyStruct()
+
+	g.generateServiceFilter()
 
-	g.generateProxyStruct(file.GetPackage())
+	g.generateRoutedMethods()
 
-	g.generateUnaryInterceptor(file.GetPackage())
+	g.generateMethodRoles()
 
-	g.generateUnaryProxyRouter(file.GetPackage())
+	g.generateUnaryInterceptor()
+
+	g.generateUnaryProxyRouter()
 
 	g.generateStreamCopyHelper()
 
-	g.generateStreamInterceptor(file.GetPackage())
+	g.generateStreamInterceptor()
+
+	g.generateStreamProxyRouter()
 
-	g.generateStreamProxyRouter(file.GetPackage())
+	g.generateFanOutTargets()
 
 	g.gen.P(g.ProxyFns.String())
 	g.gen.P("")
@@ -278,7 +400,7 @@ func (g *proxy) generate(file *generator.FileDescriptor) {
 	g.gen.P("}")
 	g.gen.P("")
 
-	g.gen.P("func (r *Registrator) Register(s *grpc.Server) {")
+	g.gen.P("func (r *Registrator) Register(s *", grpcPackage.Ident("Server"), ") {")
 	g.gen.P(g.RegistratorRegister.String())
 	g.gen.P("}")
 	g.gen.P("")
@@ -288,3 +410,92 @@ func (g *proxy) generate(file *generator.FileDescriptor) {
 
 	g.gen.P(g.GrpcClient.String())
 }
+
+// fullMethodName returns the gRPC method name as seen by the interceptors,
+// e.g. /package.Service/Method.
+func fullMethodName(service *protogen.Service, method *protogen.Method) string {
+	return fmt.Sprintf("/%s/%s", service.Desc.FullName(), method.Desc.Name())
+}
+
+// isDeprecated reports whether the method is marked as deprecated.
+func isDeprecated(method *protogen.Method) bool {
+	options, ok := method.Desc.Options().(*descriptorpb.MethodOptions)
+
+	return ok && options.GetDeprecated()
+}
+
+// Is c an ASCII lower-case letter?
+func isASCIILower(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
+
+// Is c an ASCII digit?
+func isASCIIDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// camelCase returns the CamelCased name.
+// If there is an interior underscore followed by a lower case letter,
+// drop the underscore and convert the letter to upper case.
+// There is a remote possibility of this rewrite causing a name collision,
+// but it's so remote we're prepared to pretend it's nonexistent - since the
+// C++ generator lowercases names, it's extremely unlikely to have two fields
+// with different capitalizations.
+// In short, _my_field_name_2 becomes XMyFieldName_2.
+func camelCase(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	t := make([]byte, 0, 32)
+	i := 0
+
+	if s[0] == '_' {
+		// Need a capital letter; drop the '_'.
+		t = append(t, 'X')
+		i++
+	}
+
+	// Invariant: if the next letter is lower case, it must be converted
+	// to upper case.
+	// That is, we process a word at a time, where words are marked by _ or
+	// upper case letter. Digits are treated as words.
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c == '_' && i+1 < len(s) && isASCIILower(s[i+1]) {
+			continue // Skip the underscore in s.
+		}
+
+		if isASCIIDigit(c) {
+			t = append(t, c)
+			continue
+		}
+
+		// Assume we have a letter now - if not, it's a bogus identifier.
+		// The next word is a sequence of characters that must start upper case.
+		if isASCIILower(c) {
+			c ^= ' ' // Make it a capital letter.
+		}
+
+		t = append(t, c) // Guaranteed not lower case.
+
+		// Accept lower case sequence that follows.
+		for i+1 < len(s) && isASCIILower(s[i+1]) {
+			i++
+			t = append(t, s[i])
+		}
+	}
+
+	return string(t)
+}
+
+// findField returns the field of the message with the given proto name.
+func findField(message *protogen.Message, name string) *protogen.Field {
+	for _, field := range message.Fields {
+		if string(field.Desc.Name()) == name {
+			return field
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,328 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package proxy_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/proxy"
+)
+
+var (
+	update = flag.Bool("update", false, "update the golden files")
+	stubs  = flag.Bool("stubs", false, "regenerate the stub packages with protoc-gen-go and protoc-gen-go-grpc from $PATH")
+)
+
+// genModule is the import path prefix of the generated fixtures, the files
+// get written relative to testdata/gen.
+const genModule = "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen"
+
+// fixtures are the files to generate, relative to testdata/proto.
+var fixtures = []struct {
+	file   string
+	params string
+}{
+	{file: "unary.proto", params: "default_port=50000"},
+	{file: "streaming.proto"},
+	{file: "deprecated.proto"},
+	{file: "multiservice.proto"},
+	{file: "modes.proto"},
+}
+
+func TestGolden(t *testing.T) {
+	for _, fixture := range fixtures {
+		t.Run(fixture.file, func(t *testing.T) {
+			req := codeGeneratorRequest(t, fixture.file, fixture.params)
+
+			resp := runPlugin(t, req)
+
+			if len(resp.File) == 0 {
+				t.Fatal("no file generated")
+			}
+
+			for _, f := range resp.File {
+				path := filepath.Join("testdata", "gen", f.GetName())
+
+				if *update {
+					writeFile(t, path, f.GetContent())
+
+					continue
+				}
+
+				expected, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("failed to read golden file: %s", err)
+				}
+
+				if !bytes.Equal(expected, []byte(f.GetContent())) {
+					t.Errorf("%s doesn't match the golden file, run go test -update to refresh it", path)
+				}
+			}
+
+			if *stubs {
+				generateStubs(t, req)
+			}
+		})
+	}
+
+	t.Run("compile", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("skipping in short mode")
+		}
+
+		compile(t)
+	})
+}
+
+func TestInvalidProvider(t *testing.T) {
+	req := codeGeneratorRequest(t, "unary.proto", "provider=CertificateProvider")
+
+	var params proxy.Params
+
+	plugin, err := protogen.Options{ParamFunc: params.Flags().Set}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = proxy.Generate(plugin, params); err == nil {
+		t.Fatal("expected an error for an invalid provider")
+	}
+}
+
+func TestUnsupportedAggregation(t *testing.T) {
+	for _, method := range []string{"Reboot", "Ambiguous", "Untagged"} {
+		t.Run(method, func(t *testing.T) {
+			req := codeGeneratorRequest(t, "invalid.proto", "")
+
+			// only keep the method under test
+			for _, f := range req.ProtoFile {
+				if f.GetName() != "shape/shape.proto" {
+					continue
+				}
+
+				service := f.Service[0]
+
+				for _, m := range service.Method {
+					if m.GetName() == method {
+						service.Method = []*descriptorpb.MethodDescriptorProto{m}
+
+						break
+					}
+				}
+			}
+
+			var params proxy.Params
+
+			plugin, err := protogen.Options{ParamFunc: params.Flags().Set}.New(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = proxy.Generate(plugin, params)
+			if err == nil {
+				t.Fatal("expected an error for an unsupported response")
+			}
+
+			if !strings.Contains(err.Error(), "shape.Shape."+method) {
+				t.Errorf("error doesn't name the method: %s", err)
+			}
+		})
+	}
+}
+
+// codeGeneratorRequest builds the request protoc would send for the fixture.
+func codeGeneratorRequest(t *testing.T, file, params string) *pluginpb.CodeGeneratorRequest {
+	t.Helper()
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: []string{filepath.Join("testdata", "proto"), filepath.Join("..", "..")},
+		}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+
+	files, err := compiler.Compile(context.Background(), file)
+	if err != nil {
+		t.Fatalf("failed to compile %s: %s", file, err)
+	}
+
+	parameter := "module=" + genModule
+	if params != "" {
+		parameter += "," + params
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file},
+		Parameter:      proto.String(parameter),
+	}
+
+	seen := make(map[string]struct{})
+
+	// dependencies come first, as protoc does
+	var add func(protoreflect.FileDescriptor)
+
+	add = func(fd protoreflect.FileDescriptor) {
+		if _, ok := seen[fd.Path()]; ok {
+			return
+		}
+
+		seen[fd.Path()] = struct{}{}
+
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+
+		req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(fd))
+	}
+
+	for _, fd := range files {
+		add(fd)
+	}
+
+	// Go over the wire as protoc does, the options then get resolved
+	// against the registered extensions instead of the compiler ones.
+	data, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req = &pluginpb.CodeGeneratorRequest{}
+
+	if err = proto.Unmarshal(data, req); err != nil {
+		t.Fatal(err)
+	}
+
+	return req
+}
+
+// runPlugin runs the plugin in-process.
+func runPlugin(t *testing.T, req *pluginpb.CodeGeneratorRequest) *pluginpb.CodeGeneratorResponse {
+	t.Helper()
+
+	var params proxy.Params
+
+	plugin, err := protogen.Options{ParamFunc: params.Flags().Set}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = proxy.Generate(plugin, params); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := plugin.Response()
+	if resp.Error != nil {
+		t.Fatal(resp.GetError())
+	}
+
+	return resp
+}
+
+// generateStubs runs protoc-gen-go and protoc-gen-go-grpc over the fixture
+// and its dependencies to refresh the stub packages the generated code is
+// compiled against.
+func generateStubs(t *testing.T, req *pluginpb.CodeGeneratorRequest) {
+	t.Helper()
+
+	stubReq := proto.Clone(req).(*pluginpb.CodeGeneratorRequest)
+	stubReq.Parameter = proto.String("module=" + genModule)
+	stubReq.FileToGenerate = nil
+
+	for _, f := range stubReq.ProtoFile {
+		if filepath.Dir(f.GetOptions().GetGoPackage()) == genModule {
+			stubReq.FileToGenerate = append(stubReq.FileToGenerate, f.GetName())
+		}
+	}
+
+	in, err := proto.Marshal(stubReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, plugin := range []string{"protoc-gen-go", "protoc-gen-go-grpc"} {
+		var out bytes.Buffer
+
+		cmd := exec.Command(plugin)
+		cmd.Stdin = bytes.NewReader(in)
+		cmd.Stdout = &out
+		cmd.Stderr = os.Stderr
+
+		if err = cmd.Run(); err != nil {
+			t.Fatalf("failed to run %s: %s", plugin, err)
+		}
+
+		var resp pluginpb.CodeGeneratorResponse
+
+		if err = proto.Unmarshal(out.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Error != nil {
+			t.Fatalf("%s: %s", plugin, resp.GetError())
+		}
+
+		for _, f := range resp.File {
+			writeFile(t, filepath.Join("testdata", "gen", f.GetName()), f.GetContent())
+		}
+	}
+}
+
+// compile type checks the generated packages against the stub packages. The
+// module files are copied over so the dependencies of the generated code
+// don't leak into the go.mod of the plugin.
+func compile(t *testing.T) {
+	t.Helper()
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	dir := t.TempDir()
+
+	for _, name := range []string{"go.mod", "go.sum"} {
+		contents, err := os.ReadFile(filepath.Join("..", "..", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		writeFile(t, filepath.Join(dir, name), string(contents))
+	}
+
+	cmd := exec.Command(goBin, "vet", "-mod=mod", "-modfile="+filepath.Join(dir, "go.mod"), "./testdata/gen/...")
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("generated code doesn't compile: %s\n%s", err, out)
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
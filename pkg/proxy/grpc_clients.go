@@ -5,31 +5,37 @@
 package proxy
 
 import (
-	"strings"
-
-	"github.com/golang/protobuf/protoc-gen-go/descriptor"
-	"github.com/golang/protobuf/protoc-gen-go/generator"
+	"google.golang.org/protobuf/compiler/protogen"
 )
 
 // generateLocalClient generates a local ( as in served by the host itself )
 // client to connect with the other node local grpc endpoints.
-func (g *proxy) generateLocalClient(serviceName, pkgName string) {
+func (g *proxy) generateLocalClient(service *protogen.Service) {
+	serviceName := service.GoName
+
 	// type
 	g.P(g.GrpcClient, "type Local"+serviceName+"Client struct {")
-	g.P(g.GrpcClient, pkgName+"."+serviceName+"Client")
+	g.P(g.GrpcClient, g.serviceIdent(service, "", "Client"))
 	g.P(g.GrpcClient, "}")
 	g.P(g.GrpcClient, "")
 
 	// constructor
-	g.P(g.GrpcClient, "func NewLocal"+serviceName+"Client() ("+pkgName+"."+serviceName+"Client, error) {")
-	g.P(g.GrpcClient, "conn, err := grpc.Dial(\"unix:\"+constants."+serviceName+"SocketPath,")
-	g.P(g.GrpcClient, "grpc.WithInsecure(),")
+	g.P(g.GrpcClient, "func NewLocal"+serviceName+"Client() (", g.serviceIdent(service, "", "Client"), ", error) {")
+	var socketPath string
+	if g.params.SocketPaths != "" {
+		socketPath = g.gen.QualifiedGoIdent(protogen.GoImportPath(g.params.SocketPaths).Ident(serviceName + "SocketPath"))
+	} else {
+		socketPath = g.gen.QualifiedGoIdent(runtimePackage.Ident("SocketPath")) + "(\"" + string(service.Desc.FullName()) + "\")"
+	}
+
+	g.P(g.GrpcClient, "conn, err := ", grpcPackage.Ident("Dial"), "(\"unix:\"+"+socketPath+",")
+	g.P(g.GrpcClient, grpcPackage.Ident("WithInsecure"), "(),")
 	g.P(g.GrpcClient, ")")
 	g.P(g.GrpcClient, "if err != nil {")
 	g.P(g.GrpcClient, "return nil, err")
 	g.P(g.GrpcClient, "}")
 	g.P(g.GrpcClient, "return &Local"+serviceName+"Client{")
-	g.P(g.GrpcClient, serviceName+"Client: "+pkgName+".New"+serviceName+"Client(conn),")
+	g.P(g.GrpcClient, serviceName+"Client: ", g.serviceIdent(service, "New", "Client"), "(conn),")
 	g.P(g.GrpcClient, "}, nil")
 	g.P(g.GrpcClient, "}")
 	g.P(g.GrpcClient, "")
@@ -37,29 +43,33 @@ func (g *proxy) generateLocalClient(serviceName, pkgName string) {
 
 // generateClientMethods generates the methods to satisfy the XXClient interface.
 // These methods are part of the Local<serviceName>Client struct.
-func (g *proxy) generateClientMethods(serviceName, pkgName string, method *descriptor.MethodDescriptorProto) {
-	// method arguments
-	var args strings.Builder
-	args.WriteString("(")
-	args.WriteString("ctx context.Context")
-	args.WriteString(", in *" + g.typeName(method.GetInputType()))
-	args.WriteString(", opts ...grpc.CallOption")
-	args.WriteString(")")
-
+func (g *proxy) generateClientMethods(service *protogen.Service, method *protogen.Method) {
 	// method returns
-	var returns strings.Builder
-	returns.WriteString("(")
-	if method.GetServerStreaming() || method.GetClientStreaming() {
-		returns.WriteString(pkgName + "." + serviceName + "_" + generator.CamelCase(method.GetName()) + "Client")
+	var returns interface{}
+	if method.Desc.IsStreamingServer() || method.Desc.IsStreamingClient() {
+		returns = g.serviceIdent(service, "", "_"+method.GoName+"Client")
 	} else {
-		returns.WriteString("*" + g.typeName(method.GetOutputType()))
+		returns = "*" + g.typeName(method.Output)
+	}
+
+	// client streams don't get the request upfront
+	if method.Desc.IsStreamingClient() {
+		g.P(g.GrpcClient, "func (c *Local"+service.GoName+"Client) "+method.GoName+"(",
+			"ctx ", contextPackage.Ident("Context"),
+			", opts ...", grpcPackage.Ident("CallOption"),
+			") (", returns, ", error) {")
+		g.P(g.GrpcClient, "return c."+service.GoName+"Client."+method.GoName+"(ctx, opts...)")
+		g.P(g.GrpcClient, "}")
+
+		return
 	}
-	returns.WriteString(", error")
-	returns.WriteString(")")
 
 	// method definition
-	g.P(g.GrpcClient, "func (c *Local"+serviceName+"Client) "+method.GetName()+args.String()+returns.String()+"{")
-	g.P(g.GrpcClient, "return c."+serviceName+"Client."+method.GetName()+"(ctx, in, opts...)")
+	g.P(g.GrpcClient, "func (c *Local"+service.GoName+"Client) "+method.GoName+"(",
+		"ctx ", contextPackage.Ident("Context"),
+		", in *"+g.typeName(method.Input),
+		", opts ...", grpcPackage.Ident("CallOption"),
+		") (", returns, ", error) {")
+	g.P(g.GrpcClient, "return c."+service.GoName+"Client."+method.GoName+"(ctx, in, opts...)")
 	g.P(g.GrpcClient, "}")
-
 }
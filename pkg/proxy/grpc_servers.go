@@ -5,72 +5,85 @@
 package proxy
 
 import (
-	"strings"
-
-	"github.com/golang/protobuf/protoc-gen-go/descriptor"
-	"github.com/golang/protobuf/protoc-gen-go/generator"
+	"google.golang.org/protobuf/compiler/protogen"
 )
 
 // generateRegistrator generates the embedded types for the registrator.
-func (g *proxy) generateRegistrator(service *descriptor.ServiceDescriptorProto, pkgName string) {
-	serviceName := generator.CamelCase(service.GetName())
-	g.P(g.Registrator, pkgName+"."+serviceName+"Client")
+func (g *proxy) generateRegistrator(service *protogen.Service) {
+	g.P(g.Registrator, g.serviceIdent(service, "", "Client"))
+	g.P(g.Registrator, g.serviceIdent(service, "Unimplemented", "Server"))
 }
 
 // generateRegistratorRegister generates the grpc server registration calls.
-func (g *proxy) generateRegistratorRegister(service *descriptor.ServiceDescriptorProto, pkgName string) {
-	serviceName := generator.CamelCase(service.GetName())
-	g.P(g.RegistratorRegister, pkgName+"."+"Register"+serviceName+"Server(s,r)")
+func (g *proxy) generateRegistratorRegister(service *protogen.Service) {
+	g.P(g.RegistratorRegister, g.serviceIdent(service, "Register", "Server"), "(s,r)")
 }
 
 // generateGRPCServers generates the methods to satisfy the XXServer interface.
 // This differs ever so slightly from the XXClient interface.
-func (g *proxy) generateServerMethods(serviceName, pkgName string, method *descriptor.MethodDescriptorProto) {
-	if method.GetServerStreaming() || method.GetClientStreaming() {
-		g.generateServerStreamMethods(serviceName, pkgName, method)
+func (g *proxy) generateServerMethods(service *protogen.Service, method *protogen.Method) {
+	if method.Desc.IsStreamingServer() || method.Desc.IsStreamingClient() {
+		g.generateServerStreamMethods(service, method)
 	} else {
-		g.generateServerUnaryMethods(serviceName, pkgName, method)
+		g.generateServerUnaryMethods(service, method)
 	}
 }
 
-func (g *proxy) generateServerUnaryMethods(serviceName, pkgName string, method *descriptor.MethodDescriptorProto) {
-	var serverArgs strings.Builder
-	serverArgs.WriteString("(")
-	serverArgs.WriteString("ctx context.Context, ")
-	serverArgs.WriteString("in *" + g.typeName(method.GetInputType()))
-	serverArgs.WriteString(")")
-
-	var serverReturns strings.Builder
-	serverReturns.WriteString("(")
-	serverReturns.WriteString("*" + g.typeName(method.GetOutputType()) + ", ")
-	serverReturns.WriteString("error")
-	serverReturns.WriteString(")")
-
-	g.P(g.GrpcServer, "func (r *Registrator) "+method.GetName()+serverArgs.String()+serverReturns.String()+"{")
-	g.P(g.GrpcServer, "return r."+serviceName+"Client."+method.GetName()+"(ctx, in)")
+func (g *proxy) generateServerUnaryMethods(service *protogen.Service, method *protogen.Method) {
+	g.P(g.GrpcServer, "func (r *Registrator) "+method.GoName+"(",
+		"ctx ", contextPackage.Ident("Context"), ", ",
+		"in *"+g.typeName(method.Input),
+		") (",
+		"*"+g.typeName(method.Output)+", ",
+		"error",
+		") {")
+	g.P(g.GrpcServer, "return r."+service.GoName+"Client."+method.GoName+"(ctx, in)")
 	g.P(g.GrpcServer, "}")
 }
-func (g *proxy) generateServerStreamMethods(serviceName, pkgName string, method *descriptor.MethodDescriptorProto) {
-	var serverArgs strings.Builder
-	serverArgs.WriteString("(")
-	serverArgs.WriteString("in *" + g.typeName(method.GetInputType()))
-	serverArgs.WriteString(", srv " + pkgName + "." + serviceName + "_" + generator.CamelCase(method.GetName()) + "Server, ")
-	serverArgs.WriteString(")")
 
-	var serverReturns strings.Builder
-	serverReturns.WriteString("(")
-	serverReturns.WriteString("error")
-	serverReturns.WriteString(")")
+func (g *proxy) generateServerStreamMethods(service *protogen.Service, method *protogen.Method) {
+	streamServer := g.serviceIdent(service, "", "_"+method.GoName+"Server")
 
-	g.P(g.GrpcServer, "func (r *Registrator) "+method.GetName()+
-		serverArgs.String()+serverReturns.String()+"{")
+	switch {
+	case method.Desc.IsStreamingClient() && method.Desc.IsStreamingServer():
+		g.P(g.GrpcServer, "func (r *Registrator) "+method.GoName+"(srv ", streamServer, ") error {")
+		g.P(g.GrpcServer, "ctx, cancel := ", contextPackage.Ident("WithCancel"), "(srv.Context())")
+		g.P(g.GrpcServer, "defer cancel()")
+		g.P(g.GrpcServer, "client, err := r."+service.GoName+"Client."+method.GoName+"(ctx)")
+		g.P(g.GrpcServer, "if err != nil {")
+		g.P(g.GrpcServer, "return err")
+		g.P(g.GrpcServer, "}")
+		g.P(g.GrpcServer, "return copyBidiStream("+g.newMsgFunc(method.Input)+", "+g.newMsgFunc(method.Output)+", srv, client, cancel)")
+		g.P(g.GrpcServer, "}")
+	case method.Desc.IsStreamingClient():
+		g.P(g.GrpcServer, "func (r *Registrator) "+method.GoName+"(srv ", streamServer, ") error {")
+		g.P(g.GrpcServer, "client, err := r."+service.GoName+"Client."+method.GoName+"(srv.Context())")
+		g.P(g.GrpcServer, "if err != nil {")
+		g.P(g.GrpcServer, "return err")
+		g.P(g.GrpcServer, "}")
+		g.P(g.GrpcServer, "if err = copyServerClient("+g.newMsgFunc(method.Input)+", srv, client); err != nil {")
+		g.P(g.GrpcServer, "return err")
+		g.P(g.GrpcServer, "}")
+		g.P(g.GrpcServer, "resp, err := client.CloseAndRecv()")
+		g.P(g.GrpcServer, "if err != nil {")
+		g.P(g.GrpcServer, "return err")
+		g.P(g.GrpcServer, "}")
+		g.P(g.GrpcServer, "return srv.SendAndClose(resp)")
+		g.P(g.GrpcServer, "}")
+	default:
+		g.P(g.GrpcServer, "func (r *Registrator) "+method.GoName+"(",
+			"in *"+g.typeName(method.Input),
+			", srv ", streamServer, ", ",
+			") (",
+			"error",
+			") {")
 
-	g.P(g.GrpcServer, "client, err := r."+serviceName+"Client."+method.GetName()+"(srv.Context(), in)")
-	g.P(g.GrpcServer, "if err != nil {")
-	g.P(g.GrpcServer, "return err")
-	g.P(g.GrpcServer, "}")
-	g.P(g.GrpcServer, "var msg "+g.typeName(method.GetOutputType()))
-	g.P(g.GrpcServer, "return copyClientServer(&msg, client, srv)")
+		g.P(g.GrpcServer, "client, err := r."+service.GoName+"Client."+method.GoName+"(srv.Context(), in)")
+		g.P(g.GrpcServer, "if err != nil {")
+		g.P(g.GrpcServer, "return err")
+		g.P(g.GrpcServer, "}")
+		g.P(g.GrpcServer, "return copyClientServer("+g.newMsgFunc(method.Output)+", client, srv)")
 
-	g.P(g.GrpcServer, "}")
+		g.P(g.GrpcServer, "}")
+	}
 }
@@ -4,36 +4,50 @@
 
 package proxy
 
-import "github.com/golang/protobuf/protoc-gen-go/generator"
+import (
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
 
 // generateUnaryInterceptor is a method of the proxy struct that satisfies the
 // grpc.UnaryInterceptor interface. This allows us to make use of the tls
 // information from the provider to include it with each subsequent request
 // from the proxy. This is also where we handle some of the routing decisions,
-// namely being able to filter on the supported service and handling the
-// 'proxyfrom' metadata field to prevent infinite loops.
-func (g *proxy) generateUnaryInterceptor(serviceName string) {
-	tName := generator.CamelCase(serviceName + "_proxy")
-	g.gen.P("func (p *" + tName + ") UnaryInterceptor() grpc.UnaryServerInterceptor {")
-	g.gen.P("return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {")
-	g.gen.P("md, _ := metadata.FromIncomingContext(ctx)")
-	g.gen.P("if _, ok := md[\"proxyfrom\"]; ok {")
+// namely being able to filter on the supported service and following the
+// route of the request in the 'proxy-via' metadata field to prevent loops.
+func (g *proxy) generateUnaryInterceptor() {
+	tName := g.proxyName()
+	g.gen.P("func (p *"+tName+") UnaryInterceptor() ", grpcPackage.Ident("UnaryServerInterceptor"), " {")
+	g.gen.P("return func(ctx ", contextPackage.Ident("Context"), ", req interface{}, info *", grpcPackage.Ident("UnaryServerInfo"), ", handler ", grpcPackage.Ident("UnaryHandler"), ") (interface{}, error) {")
+	g.gen.P("md, _ := ", metadataPackage.Ident("FromIncomingContext"), "(ctx)")
+	g.gen.P("if !proxiedService(info.FullMethod) {")
 	g.gen.P("return handler(ctx, req)")
 	g.gen.P("}")
-	g.gen.P("ca, err := p.Provider.GetCA()")
+	g.gen.P("// the roles are checked whether the request is proxied or not, the")
+	g.gen.P("// route metadata being set by the callers")
+	g.gen.P("if err := ", runtimePackage.Ident("CheckRoles"), "(ctx, info.FullMethod, methodRoles(info.FullMethod)); err != nil {")
+	g.gen.P("return nil, err")
+	g.gen.P("}")
+	g.gen.P("forward, err := ", runtimePackage.Ident("Forward"), "(md, p.Name, p.MaxHops)")
 	g.gen.P("if err != nil {")
-	g.gen.P("	return nil, err")
+	g.gen.P("return nil, err")
+	g.gen.P("}")
+	g.gen.P("if !forward {")
+	g.gen.P("return handler(ctx, req)")
 	g.gen.P("}")
-	g.gen.P("certs, err := p.Provider.GetCertificate(nil)")
+	g.generateLocalSwitch("return handler(ctx, req)")
+	g.gen.P("if !routedMethod(info.FullMethod) {")
+	g.gen.P("if p.UnaryFallback != nil {")
+	g.gen.P("return p.UnaryFallback(ctx, req, info, handler)")
+	g.gen.P("}")
+	g.gen.P("return nil, ", runtimePackage.Ident("ErrUnknownMethod"))
+	g.gen.P("}")
+	g.gen.P("creds, err := p.Credentials.Get(p.Provider)")
 	g.gen.P("if err != nil {")
-	g.gen.P("  return nil, err")
+	g.gen.P("	return nil, err")
 	g.gen.P("}")
-	g.gen.P("tlsConfig, err := tls.New(")
-	g.gen.P("  tls.WithClientAuthType(tls.Mutual),")
-	g.gen.P("  tls.WithCACertPEM(ca),")
-	g.gen.P("  tls.WithKeypair(*certs),")
-	g.gen.P(")")
-	g.gen.P("return p.UnaryProxy(ctx, info.FullMethod, credentials.NewTLS(tlsConfig), req)")
+	g.gen.P("return p.UnaryProxy(ctx, info.FullMethod, creds, req)")
 	g.gen.P("}")
 	g.gen.P("}")
 	g.gen.P("")
@@ -43,31 +57,117 @@ func (g *proxy) generateUnaryInterceptor(serviceName string) {
 // grpc.UnaryInterceptor interface. This allows us to make use of the tls
 // information from the provider to include it with each subsequent request
 // from the proxy. This is also where we handle some of the routing decisions,
-// namely being able to filter on the supported service and handling the
-// 'proxyfrom' metadata field to prevent infinite loops.
-func (g *proxy) generateStreamInterceptor(serviceName string) {
-	tName := generator.CamelCase(serviceName + "_proxy")
-	g.gen.P("func (p *" + tName + ") StreamInterceptor() grpc.StreamServerInterceptor {")
-	g.gen.P("return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {")
-	g.gen.P("md, _ := metadata.FromIncomingContext(ss.Context())")
-	g.gen.P("if _, ok := md[\"proxyfrom\"]; ok {")
+// namely being able to filter on the supported service and following the
+// route of the request in the 'proxy-via' metadata field to prevent loops.
+func (g *proxy) generateStreamInterceptor() {
+	tName := g.proxyName()
+	g.gen.P("func (p *"+tName+") StreamInterceptor() ", grpcPackage.Ident("StreamServerInterceptor"), " {")
+	g.gen.P("return func(srv interface{}, ss ", grpcPackage.Ident("ServerStream"), ", info *", grpcPackage.Ident("StreamServerInfo"), ", handler ", grpcPackage.Ident("StreamHandler"), ") error {")
+	g.gen.P("md, _ := ", metadataPackage.Ident("FromIncomingContext"), "(ss.Context())")
+	g.gen.P("if !proxiedService(info.FullMethod) {")
 	g.gen.P("return handler(srv, ss)")
 	g.gen.P("}")
-	g.gen.P("ca, err := p.Provider.GetCA()")
+	g.gen.P("// the roles are checked whether the request is proxied or not, the")
+	g.gen.P("// route metadata being set by the callers")
+	g.gen.P("if err := ", runtimePackage.Ident("CheckRoles"), "(ss.Context(), info.FullMethod, methodRoles(info.FullMethod)); err != nil {")
+	g.gen.P("return err")
+	g.gen.P("}")
+	g.gen.P("forward, err := ", runtimePackage.Ident("Forward"), "(md, p.Name, p.MaxHops)")
 	g.gen.P("if err != nil {")
-	g.gen.P("	return err")
+	g.gen.P("return err")
 	g.gen.P("}")
-	g.gen.P("certs, err := p.Provider.GetCertificate(nil)")
+	g.gen.P("if !forward {")
+	g.gen.P("return handler(srv, ss)")
+	g.gen.P("}")
+	g.generateLocalSwitch("return handler(srv, ss)")
+	g.gen.P("if !routedMethod(info.FullMethod) {")
+	g.gen.P("if p.StreamFallback != nil {")
+	g.gen.P("return p.StreamFallback(srv, ss, info, handler)")
+	g.gen.P("}")
+	g.gen.P("return ", runtimePackage.Ident("ErrUnknownMethod"))
+	g.gen.P("}")
+	g.gen.P("creds, err := p.Credentials.Get(p.Provider)")
 	g.gen.P("if err != nil {")
-	g.gen.P("  return err")
+	g.gen.P("	return err")
 	g.gen.P("}")
-	g.gen.P("tlsConfig, err := tls.New(")
-	g.gen.P("  tls.WithClientAuthType(tls.Mutual),")
-	g.gen.P("  tls.WithCACertPEM(ca),")
-	g.gen.P("  tls.WithKeypair(*certs),")
-	g.gen.P(")")
-	g.gen.P("return p.StreamProxy(ss, info.FullMethod, credentials.NewTLS(tlsConfig), srv)")
+	g.gen.P("return p.StreamProxy(ss, info.FullMethod, creds, srv)")
 	g.gen.P("}")
 	g.gen.P("}")
 	g.gen.P("")
 }
+
+// generateLocalSwitch hands the methods marked as LOCAL_ONLY over to the local
+// handler.
+func (g *proxy) generateLocalSwitch(handle string) {
+	if len(g.localMethods) == 0 {
+		return
+	}
+
+	g.gen.P("switch info.FullMethod {")
+	g.gen.P("case \"" + strings.Join(g.localMethods, "\", \"") + "\":")
+	g.gen.P(handle)
+	g.gen.P("}")
+}
+
+// generateServiceFilter generates the check letting the interceptors leave the
+// services this proxy doesn't know about, e.g. health checks or reflection
+// served next to the proxied ones, to the local server.
+func (g *proxy) generateServiceFilter() {
+	g.gen.P("func proxiedService(fullMethod string) bool {")
+	g.gen.P("switch ", runtimePackage.Ident("ServiceName"), "(fullMethod) {")
+	g.gen.P("case \"" + strings.Join(g.services, "\", \"") + "\":")
+	g.gen.P("return true")
+	g.gen.P("}")
+	g.gen.P("return false")
+	g.gen.P("}")
+	g.gen.P("")
+}
+
+// generateRoutedMethods generates the lookup of the methods UnaryProxy and
+// StreamProxy route. The interceptors hand the other methods of the proxied
+// services over to the fallbacks before proxying anything: an Unimplemented
+// error coming back from a target doesn't tell whether the method is unknown
+// to this proxy or to the target.
+func (g *proxy) generateRoutedMethods() {
+	g.gen.P("func routedMethod(fullMethod string) bool {")
+
+	if len(g.routedMethods) > 0 {
+		g.gen.P("switch fullMethod {")
+		g.gen.P("case \"" + strings.Join(g.routedMethods, "\", \"") + "\":")
+		g.gen.P("return true")
+		g.gen.P("}")
+	}
+
+	g.gen.P("return false")
+	g.gen.P("}")
+	g.gen.P("")
+}
+
+// collectRoles records the roles allowed to call a method of the proxied
+// services.
+func (g *proxy) collectRoles(service *protogen.Service, method *protogen.Method) {
+	roles := methodOptions(service, method).GetRoles()
+	if len(roles) == 0 {
+		return
+	}
+
+	g.P(g.MethodRoles, "case \""+fullMethodName(service, method)+"\":")
+	g.P(g.MethodRoles, "return []string{\""+strings.Join(roles, "\", \"")+"\"}")
+}
+
+// generateMethodRoles generates the lookup of the roles allowed to call the
+// methods of the proxied services, as declared by the (proxy.method).roles
+// options.
+func (g *proxy) generateMethodRoles() {
+	g.gen.P("func methodRoles(fullMethod string) []string {")
+
+	if g.MethodRoles.Len() > 0 {
+		g.gen.P("switch fullMethod {")
+		g.gen.P(g.MethodRoles.String())
+		g.gen.P("}")
+	}
+
+	g.gen.P("return nil")
+	g.gen.P("}")
+	g.gen.P("")
+}
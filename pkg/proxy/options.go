@@ -0,0 +1,193 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package proxy
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+
+	options "github.com/talos-systems/protoc-gen-proxy/proxy"
+)
+
+// methodOptions returns the (proxy.method) options of the method merged on top
+// of the (proxy.service) options of its service.
+func methodOptions(service *protogen.Service, method *protogen.Method) *options.MethodOptions {
+	merged := &options.MethodOptions{
+		Aggregation: &options.Aggregation{},
+		Execution:   &options.Execution{},
+		Call:        &options.CallOptions{},
+	}
+
+	if opts, ok := proto.GetExtension(service.Desc.Options(), options.E_Service).(*options.ServiceOptions); ok && opts != nil {
+		if opts.Mode != nil {
+			merged.Mode = opts.Mode
+		}
+
+		mergeAggregation(merged.Aggregation, opts.GetAggregation())
+		mergeExecution(merged.Execution, opts.GetExecution())
+		mergeCallOptions(merged.Call, opts.GetCall())
+
+		if len(opts.GetRoles()) > 0 {
+			merged.Roles = opts.GetRoles()
+		}
+	}
+
+	if opts, ok := proto.GetExtension(method.Desc.Options(), options.E_Method).(*options.MethodOptions); ok && opts != nil {
+		if opts.Mode != nil {
+			merged.Mode = opts.Mode
+		}
+
+		mergeAggregation(merged.Aggregation, opts.GetAggregation())
+		mergeExecution(merged.Execution, opts.GetExecution())
+		mergeCallOptions(merged.Call, opts.GetCall())
+
+		if len(opts.GetRoles()) > 0 {
+			merged.Roles = opts.GetRoles()
+		}
+	}
+
+	return merged
+}
+
+func mergeAggregation(dst, src *options.Aggregation) {
+	if src.GetField() != "" {
+		dst.Field = src.GetField()
+	}
+
+	if src.GetMetadataField() != "" {
+		dst.MetadataField = src.GetMetadataField()
+	}
+
+	if src != nil && src.InlineErrors != nil {
+		dst.InlineErrors = src.InlineErrors
+	}
+}
+
+func mergeExecution(dst, src *options.Execution) {
+	// all the fields track their presence
+	if src != nil {
+		proto.Merge(dst, src)
+	}
+}
+
+func mergeCallOptions(dst, src *options.CallOptions) {
+	// all the fields track their presence
+	if src != nil {
+		proto.Merge(dst, src)
+	}
+}
+
+// callOptions returns the expressions of the grpc.CallOption of the method,
+// in the order of the fields.
+func (g *proxy) callOptions(service *protogen.Service, method *protogen.Method) []string {
+	opts := methodOptions(service, method).GetCall()
+
+	var callOpts []string
+
+	if opts.MaxRecvMsgSize != nil {
+		callOpts = append(callOpts, g.gen.QualifiedGoIdent(grpcPackage.Ident("MaxCallRecvMsgSize"))+"("+strconv.FormatUint(uint64(opts.GetMaxRecvMsgSize()), 10)+")")
+	}
+
+	if opts.MaxSendMsgSize != nil {
+		callOpts = append(callOpts, g.gen.QualifiedGoIdent(grpcPackage.Ident("MaxCallSendMsgSize"))+"("+strconv.FormatUint(uint64(opts.GetMaxSendMsgSize()), 10)+")")
+	}
+
+	if opts.WaitForReady != nil {
+		callOpts = append(callOpts, g.gen.QualifiedGoIdent(grpcPackage.Ident("WaitForReady"))+"("+strconv.FormatBool(opts.GetWaitForReady())+")")
+	}
+
+	if opts.Compressor != nil {
+		callOpts = append(callOpts, g.gen.QualifiedGoIdent(grpcPackage.Ident("UseCompressor"))+"("+strconv.Quote(opts.GetCompressor())+")")
+	}
+
+	return callOpts
+}
+
+// dispatchNames maps the dispatch options to the runtime constants.
+var dispatchNames = map[options.Dispatch]string{
+	options.Dispatch_ALL:    "DispatchAll",
+	options.Dispatch_ANY:    "DispatchAny",
+	options.Dispatch_QUORUM: "DispatchQuorum",
+	options.Dispatch_ONE_OF: "DispatchOneOf",
+}
+
+// balancingNames maps the balancing options to the runtime constants.
+var balancingNames = map[options.Balancing]string{
+	options.Balancing_ROUND_ROBIN:  "RoundRobin",
+	options.Balancing_LEAST_LOADED: "LeastLoaded",
+}
+
+// methodMode returns the routing mode of the method. Deprecated methods are
+// never routed through the proxy.
+func methodMode(service *protogen.Service, method *protogen.Method) options.Mode {
+	// skip support for deprecated methods
+	if isDeprecated(method) {
+		return options.Mode_SKIP
+	}
+
+	return methodOptions(service, method).GetMode()
+}
+
+// execution returns the literal of the runtime.Execution of a fanned out
+// method.
+func (g *proxy) execution(service *protogen.Service, method *protogen.Method) string {
+	opts := methodOptions(service, method).GetExecution()
+
+	var fields []string
+
+	if opts.MaxConcurrency != nil {
+		fields = append(fields, "MaxConcurrency: "+strconv.FormatUint(uint64(opts.GetMaxConcurrency()), 10))
+	}
+
+	if opts.BatchSize != nil {
+		fields = append(fields, "BatchSize: "+strconv.FormatUint(uint64(opts.GetBatchSize()), 10))
+	}
+
+	if opts.StopOnError != nil {
+		fields = append(fields, "StopOnError: "+strconv.FormatBool(opts.GetStopOnError()))
+	}
+
+	if opts.TargetTimeout != nil {
+		fields = append(fields, "TargetTimeout: "+g.duration(opts.GetTargetTimeout().AsDuration()))
+	}
+
+	if opts.Dispatch != nil {
+		fields = append(fields, "Dispatch: "+g.gen.QualifiedGoIdent(runtimePackage.Ident(dispatchNames[opts.GetDispatch()])))
+	}
+
+	if opts.Balancing != nil {
+		fields = append(fields, "Balancing: "+g.gen.QualifiedGoIdent(runtimePackage.Ident(balancingNames[opts.GetBalancing()])))
+	}
+
+	// the load of the targets is tracked across the methods
+	fields = append(fields, "Balancer: p.Balancer")
+
+	return g.gen.QualifiedGoIdent(runtimePackage.Ident("Execution")) + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// duration returns the literal of d in the largest unit which holds it
+// exactly.
+func (g *proxy) duration(d time.Duration) string {
+	for _, unit := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{name: "Hour", value: time.Hour},
+		{name: "Minute", value: time.Minute},
+		{name: "Second", value: time.Second},
+		{name: "Millisecond", value: time.Millisecond},
+		{name: "Microsecond", value: time.Microsecond},
+	} {
+		if d%unit.value == 0 {
+			return strconv.FormatInt(int64(d/unit.value), 10) + " * " + g.gen.QualifiedGoIdent(timePackage.Ident(unit.name))
+		}
+	}
+
+	return g.gen.QualifiedGoIdent(timePackage.Ident("Duration")) + "(" + strconv.FormatInt(int64(d), 10) + ")"
+}
@@ -5,51 +5,119 @@
 package proxy
 
 import (
-	"strings"
-
-	"github.com/golang/protobuf/protoc-gen-go/generator"
+	"google.golang.org/protobuf/compiler/protogen"
 )
 
 // generateProxyClientStruct holds the client connection and additional metadata
 // associated with each grpc ( client ) connection that the proxy creates. This
 // should only exist for the duration of the request.
-func (g *proxy) generateProxyClientStruct(serviceName, pkgName string) {
-	g.P(g.ProxyFns, "type proxy"+serviceName+"Client struct {")
-	g.P(g.ProxyFns, "Conn "+pkgName+"."+serviceName+"Client")
-	g.P(g.ProxyFns, "Context context.Context")
+func (g *proxy) generateProxyClientStruct(service *protogen.Service) {
+	g.P(g.ProxyFns, "type proxy"+service.GoName+"Client struct {")
+	g.P(g.ProxyFns, "Conn ", g.serviceIdent(service, "", "Client"))
+	g.P(g.ProxyFns, "Context ", contextPackage.Ident("Context"))
 	g.P(g.ProxyFns, "Target string")
-	g.P(g.ProxyFns, "DialOpts []grpc.DialOption")
+	g.P(g.ProxyFns, "DialOpts []", grpcPackage.Ident("DialOption"))
+	g.P(g.ProxyFns, "CallOpts []", grpcPackage.Ident("CallOption"))
 	g.P(g.ProxyFns, "}")
 	g.P(g.ProxyFns, "")
 }
 
 // generateProxyStruct is the public struct exposed for use by importers. It
-// contains a tls provider to manage the TLS cert rotation/renewal. This also
-// generates the constructor for the struct.
-func (g *proxy) generateProxyStruct(serviceName string) {
-	tName := generator.CamelCase(serviceName + "_proxy")
+// contains a tls provider to manage the TLS cert rotation/renewal, the pool
+// of the connections to the targets and the balancer of their load. This also generates the constructor for
+// the struct and its Close method.
+func (g *proxy) generateProxyStruct() {
+	tName := g.proxyName()
+	// validated by Generate
+	provider, _ := g.params.providerIdent() //nolint:errcheck
+
 	g.gen.P("type " + tName + " struct {")
-	g.gen.P("Provider tls.CertificateProvider")
+	g.gen.P("Provider ", provider)
+	g.gen.P("// Credentials caches the credentials built from the Provider until it")
+	g.gen.P("// signals a rotation, a nil Credentials builds them on each request.")
+	g.gen.P("Credentials *", runtimePackage.Ident("CredentialsCache"))
+	g.gen.P("// CredentialsFor returns the credentials to dial a target with, e.g. to")
+	g.gen.P("// trust the CA of its cluster. The targets it returns nil credentials")
+	g.gen.P("// for, or all of them when unset, are dialed with the Credentials.")
+	g.gen.P("CredentialsFor ", runtimePackage.Ident("CredentialsFunc"))
+	g.gen.P("// VerifyTargetName requires the certificate of each target to be valid")
+	g.gen.P("// for the host it was requested as.")
+	g.gen.P("VerifyTargetName bool")
+	g.gen.P("// Name identifies the proxy in the route of the requests, it defaults to")
+	g.gen.P("// the authority of each request.")
+	g.gen.P("Name string")
+	g.gen.P("// MaxHops is the number of proxies a request may go through, the")
+	g.gen.P("// requests which went through as many get handled locally. Zero only")
+	g.gen.P("// lets a single proxy forward them.")
+	g.gen.P("MaxHops int")
+	g.gen.P("// DefaultPort is dialed when a target doesn't specify a port.")
+	g.gen.P("DefaultPort int")
+	g.gen.P("// Pool holds the connections to the targets, a nil Pool dials them on")
+	g.gen.P("// each request.")
+	g.gen.P("Pool *", runtimePackage.Ident("Pool"))
+	g.gen.P("// DialOptions are applied when dialing the targets, e.g. keepalives,")
+	g.gen.P("// message sizes or a custom dialer. The pooled connections keep the")
+	g.gen.P("// options they were dialed with.")
+	g.gen.P("DialOptions []", grpcPackage.Ident("DialOption"))
+	g.gen.P("// Dialer creates the connections to the targets, e.g. to in-memory")
+	g.gen.P("// servers in tests with a runtimetest.BufconnDialer. The targets are dialed")
+	g.gen.P("// over the network when unset.")
+	g.gen.P("Dialer ", runtimePackage.Ident("Dialer"))
+	g.gen.P("// Balancer spreads the methods dispatched to one of their targets, it")
+	g.gen.P("// tracks the calls in flight to each target.")
+	g.gen.P("Balancer *", runtimePackage.Ident("Balancer"))
+	g.gen.P("// ForwardedMetadata lists the keys of the incoming metadata passed on to")
+	g.gen.P("// the targets.")
+	g.gen.P("ForwardedMetadata []string")
+	g.gen.P("// Resolver expands the logical targets of the requests, such as \"all\",")
+	g.gen.P("// into the addresses of the nodes. The targets are used as is when unset.")
+	g.gen.P("Resolver ", runtimePackage.Ident("Resolver"))
+	g.gen.P("// TargetPolicy restricts the targets the requests may ask for, any")
+	g.gen.P("// target is allowed when unset.")
+	g.gen.P("TargetPolicy *", runtimePackage.Ident("TargetPolicy"))
+	g.gen.P("// Authorizer decides whether the requests get proxied and may narrow")
+	g.gen.P("// their targets, once the caller is checked against the roles of the")
+	g.gen.P("// method.")
+	g.gen.P("Authorizer ", runtimePackage.Ident("Authorizer"))
+	g.gen.P("// UnaryFallback and StreamFallback handle the methods of the proxied")
+	g.gen.P("// services the proxy doesn't route, those fail with Unimplemented when")
+	g.gen.P("// unset. runtime.LocalUnaryFallback and runtime.LocalStreamFallback")
+	g.gen.P("// hand them over to the local server.")
+	g.gen.P("UnaryFallback ", grpcPackage.Ident("UnaryServerInterceptor"))
+	g.gen.P("StreamFallback ", grpcPackage.Ident("StreamServerInterceptor"))
 	g.gen.P("}")
 	g.gen.P("")
 
-	var args strings.Builder
-	args.WriteString("(")
-	args.WriteString("provider tls.CertificateProvider")
-	args.WriteString(")")
-	g.gen.P("func New" + tName + args.String() + " *" + tName + "{")
+	g.gen.P("func New"+tName+"(provider ", provider, ") *"+tName+"{")
 	g.gen.P("return &" + tName + "{")
 	g.gen.P("Provider: provider,")
+	g.gen.P("Credentials: ", runtimePackage.Ident("NewCredentialsCache"), "(),")
+	g.gen.P("Pool: ", runtimePackage.Ident("NewPool"), "(", runtimePackage.Ident("DefaultIdleTimeout"), "),")
+	g.gen.P("Balancer: ", runtimePackage.Ident("NewBalancer"), "(),")
+	if g.params.DefaultPort != 0 {
+		g.gen.P("DefaultPort: ", g.params.DefaultPort, ",")
+	}
+	g.gen.P("}")
 	g.gen.P("}")
+	g.gen.P("")
+
+	g.gen.P("// Close closes the connections to the targets.")
+	g.gen.P("func (p *" + tName + ") Close() error {")
+	g.gen.P("return p.Pool.Close()")
 	g.gen.P("}")
 	g.gen.P("")
 }
 
+// generateStreamCopyHelper generates the helpers forwarding the messages
+// between the streams of the incoming request and the proxied one. A new
+// message is allocated for each message received as the messages can't be
+// modified once sent.
 func (g *proxy) generateStreamCopyHelper() {
-	g.gen.P("func copyClientServer(msg interface{}, client grpc.ClientStream, srv grpc.ServerStream) error {")
+	g.gen.P("func copyClientServer(newMsg func() interface{}, client ", grpcPackage.Ident("ClientStream"), ", srv ", grpcPackage.Ident("ServerStream"), ") error {")
 	g.gen.P("	for {")
+	g.gen.P("		msg := newMsg()")
 	g.gen.P("		err := client.RecvMsg(msg)")
-	g.gen.P("		if err == io.EOF {")
+	g.gen.P("		if err == ", ioPackage.Ident("EOF"), " {")
 	g.gen.P("			break")
 	g.gen.P("		}")
 	g.gen.P("")
@@ -66,4 +134,76 @@ func (g *proxy) generateStreamCopyHelper() {
 	g.gen.P("	return nil")
 	g.gen.P("}")
 	g.gen.P("")
+
+	g.gen.P("func copyServerClient(newMsg func() interface{}, srv ", grpcPackage.Ident("ServerStream"), ", client ", grpcPackage.Ident("ClientStream"), ") error {")
+	g.gen.P("	for {")
+	g.gen.P("		msg := newMsg()")
+	g.gen.P("		err := srv.RecvMsg(msg)")
+	g.gen.P("		if err == ", ioPackage.Ident("EOF"), " {")
+	g.gen.P("			break")
+	g.gen.P("		}")
+	g.gen.P("")
+	g.gen.P("		if err != nil {")
+	g.gen.P("			return err")
+	g.gen.P("		}")
+	g.gen.P("")
+	g.gen.P("		err = client.SendMsg(msg)")
+	g.gen.P("		if err == ", ioPackage.Ident("EOF"), " {")
+	g.gen.P("			// the stream got aborted, the status is returned by RecvMsg")
+	g.gen.P("			break")
+	g.gen.P("		}")
+	g.gen.P("")
+	g.gen.P("		if err != nil {")
+	g.gen.P("			return err")
+	g.gen.P("		}")
+	g.gen.P("	}")
+	g.gen.P("")
+	g.gen.P("	return nil")
+	g.gen.P("}")
+	g.gen.P("")
+
+	g.gen.P("func copyBidiStream(newIn, newOut func() interface{}, srv ", grpcPackage.Ident("ServerStream"), ", client ", grpcPackage.Ident("ClientStream"), ", cancel ", contextPackage.Ident("CancelFunc"), ") error {")
+	g.gen.P("	errCh := make(chan error, 1)")
+	g.gen.P("")
+	g.gen.P("	go func() {")
+	g.gen.P("		err := copyServerClient(newIn, srv, client)")
+	g.gen.P("		if err != nil {")
+	g.gen.P("			// abort the proxied stream")
+	g.gen.P("			cancel()")
+	g.gen.P("		} else {")
+	g.gen.P("			// half-close, the responses keep flowing")
+	g.gen.P("			err = client.CloseSend()")
+	g.gen.P("		}")
+	g.gen.P("")
+	g.gen.P("		errCh <- err")
+	g.gen.P("	}()")
+	g.gen.P("")
+	g.gen.P("	if err := copyClientServer(newOut, client, srv); err != nil {")
+	g.gen.P("		select {")
+	g.gen.P("		case inErr := <-errCh:")
+	g.gen.P("			if inErr != nil {")
+	g.gen.P("				return inErr")
+	g.gen.P("			}")
+	g.gen.P("		default:")
+	g.gen.P("		}")
+	g.gen.P("")
+	g.gen.P("		return err")
+	g.gen.P("	}")
+	g.gen.P("")
+	g.gen.P("	return nil")
+	g.gen.P("}")
+	g.gen.P("")
+}
+
+// newMsgFunc returns a function literal allocating a new message.
+func (g *proxy) newMsgFunc(message *protogen.Message) string {
+	return "func() interface{} { return new(" + g.typeName(message) + ") }"
+}
+
+// serviceIdent returns the identifier of a type or function generated for the
+// service by the gRPC plugin, e.g. serviceIdent(service, "New", "Client").
+func (g *proxy) serviceIdent(service *protogen.Service, prefix, suffix string) protogen.GoIdent {
+	file := g.plugin.FilesByPath[service.Location.SourceFile]
+
+	return file.GoImportPath.Ident(prefix + service.GoName + suffix)
 }
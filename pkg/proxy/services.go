@@ -5,134 +5,131 @@
 package proxy
 
 import (
-	"strings"
+	"google.golang.org/protobuf/compiler/protogen"
 
-	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
-	"github.com/golang/protobuf/protoc-gen-go/generator"
+	options "github.com/talos-systems/protoc-gen-proxy/proxy"
 )
 
 // generateServiceFuncType is a function with a specific signature. This function
 // gets passed through the 'runner' func to perform the actual client call.
-func (g *proxy) generateServiceFuncType(serviceName string) {
-	var args strings.Builder
-	args.WriteString("(")
-	args.WriteString("*proxy" + serviceName + "Client, ")
-	args.WriteString("interface{}, ")
-	args.WriteString("*sync.WaitGroup, ")
-	args.WriteString("chan proto.Message, ")
-	args.WriteString("chan error")
-	args.WriteString(")")
-	g.P(g.ProxyFns, "type runner"+generator.CamelCase(serviceName+"_fn")+" func"+args.String())
+func (g *proxy) generateServiceFuncType(service *protogen.Service) {
+	g.P(g.ProxyFns, "type runner"+camelCase(service.GoName+"_fn")+" func(",
+		contextPackage.Ident("Context"), ", ",
+		"*proxy"+service.GoName+"Client, ",
+		"interface{}",
+		") (", protoPackage.Ident("Message"), ", error)")
 	g.P(g.ProxyFns, "")
 }
 
 // generateServiceFunc is a function generated for each service defined in the
-// proto file. The function signature satisfies the runnerfn type.
-func (g *proxy) generateServiceFunc(serviceName string, method *pb.MethodDescriptorProto) {
-	// skip support for deprecated methods
-	if method.GetOptions().GetDeprecated() {
+// proto file. The function signature satisfies the runnerfn type, the call is
+// made with the given context which is bounded by the target timeout.
+func (g *proxy) generateServiceFunc(service *protogen.Service, method *protogen.Method) {
+	// only fanned out methods aggregate the responses
+	if methodMode(service, method) != options.Mode_FANOUT {
 		return
 	}
-	var args strings.Builder
-	args.WriteString("(")
-	args.WriteString("client *proxy" + serviceName + "Client, ")
-	args.WriteString("in interface{}, ")
-	args.WriteString("wg *sync.WaitGroup, ")
-	args.WriteString("respCh chan proto.Message, ")
-	args.WriteString("errCh chan error")
-	args.WriteString(")")
 
-	g.P(g.ProxyFns, "func proxy"+generator.CamelCase(method.GetName())+args.String()+"{")
-	g.P(g.ProxyFns, "defer wg.Done()")
-	g.P(g.ProxyFns, "resp, err := client.Conn."+method.GetName()+"(client.Context, in.(*"+g.typeName(method.GetInputType())+"))")
+	agg := g.aggregations[method]
+
+	g.P(g.ProxyFns, "func proxy"+method.GoName+"(",
+		"ctx ", contextPackage.Ident("Context"), ", ",
+		"client *proxy"+service.GoName+"Client, ",
+		"in interface{}",
+		") (", protoPackage.Ident("Message"), ", error) {")
+	g.P(g.ProxyFns, "resp, err := client.Conn."+method.GoName+"(ctx, in.(*"+g.typeName(method.Input)+"), client.CallOpts...)")
 	g.P(g.ProxyFns, "if err != nil {")
-	g.P(g.ProxyFns, "errCh<-err")
-	g.P(g.ProxyFns, "return")
+	g.P(g.ProxyFns, "return nil, err")
+	g.P(g.ProxyFns, "}")
+	g.P(g.ProxyFns, "for _, msg := range resp."+agg.list.GoName+" {")
+	g.P(g.ProxyFns, g.tagMetadata(agg, "msg", "client.Target"))
 	g.P(g.ProxyFns, "}")
-	// TODO: See if we can better abstract this
-	g.P(g.ProxyFns, "resp.Response[0].Metadata = &NodeMetadata{Hostname: client.Target}")
-	g.P(g.ProxyFns, "respCh<-resp")
+	g.P(g.ProxyFns, "return resp, nil")
 	g.P(g.ProxyFns, "}")
 	g.P(g.ProxyFns, "")
 }
 
 // generateServiceRunner is the function that handles the client calls and response
-// aggregation.
-func (g *proxy) generateServiceRunner(serviceName string) {
-	var args strings.Builder
-	args.WriteString("(")
-	args.WriteString("clients []*proxy" + serviceName + "Client, ")
-	args.WriteString("in interface{}, ")
-	args.WriteString("runner runner" + generator.CamelCase(serviceName+"_fn"))
-	args.WriteString(")")
-
-	var returns strings.Builder
-	returns.WriteString("(")
-	returns.WriteString("[]proto.Message, ")
-	returns.WriteString("error")
-	returns.WriteString(")")
-
-	g.P(g.ProxyFns, "func proxy"+generator.CamelCase(serviceName+"_runner")+args.String()+returns.String()+"{")
-	g.P(g.ProxyFns, "var (")
-	g.P(g.ProxyFns, "errors *go_multierror.Error")
-	g.P(g.ProxyFns, "wg sync.WaitGroup")
-	g.P(g.ProxyFns, ")")
-
-	g.P(g.ProxyFns, "respCh := make(chan proto.Message, len(clients))")
-	g.P(g.ProxyFns, "errCh := make(chan error, len(clients))")
-	g.P(g.ProxyFns, "wg.Add(len(clients))")
-
-	g.P(g.ProxyFns, "for _, client := range clients {")
-	g.P(g.ProxyFns, "go runner(client, in, &wg, respCh, errCh)")
+// aggregation. The execution dispatches the calls to the targets, each call
+// getting its own context derived from ctx so a hung target only holds up the
+// response until its timeout fires and the calls which lost the race can be
+// cancelled.
+func (g *proxy) generateServiceRunner(service *protogen.Service) {
+	g.P(g.ProxyFns, "func proxy"+camelCase(service.GoName+"_runner")+"(",
+		"ctx ", contextPackage.Ident("Context"), ", ",
+		"clients []*proxy"+service.GoName+"Client, ",
+		"in interface{}, ",
+		"runner runner"+camelCase(service.GoName+"_fn")+", ",
+		"execution ", runtimePackage.Ident("Execution"),
+		") (",
+		"[]", protoPackage.Ident("Message"), ", ",
+		"error",
+		") {")
+	g.P(g.ProxyFns, "targets := make([]string, len(clients))")
+	g.P(g.ProxyFns, "for i, client := range clients {")
+	g.P(g.ProxyFns, "targets[i] = client.Target")
 	g.P(g.ProxyFns, "}")
-
-	g.P(g.ProxyFns, "wg.Wait()")
-	g.P(g.ProxyFns, "close(respCh)")
-	g.P(g.ProxyFns, "close(errCh)")
 	g.P(g.ProxyFns, "")
 
-	g.P(g.ProxyFns, "var response []proto.Message")
-	g.P(g.ProxyFns, "for resp := range respCh {")
-	g.P(g.ProxyFns, "response = append(response, resp)")
-	g.P(g.ProxyFns, "}")
-
-	g.P(g.ProxyFns, "for err := range errCh {")
-	g.P(g.ProxyFns, "errors = go_multierror.Append(errors, err)")
-	g.P(g.ProxyFns, "}")
-
-	g.P(g.ProxyFns, "return response, errors.ErrorOrNil()")
+	g.P(g.ProxyFns, "return execution.Run(ctx, targets, func(ctx ", contextPackage.Ident("Context"), ", i int) (", protoPackage.Ident("Message"), ", error) {")
+	g.P(g.ProxyFns, "return runner(ctx, clients[i], in)")
+	g.P(g.ProxyFns, "})")
 	g.P(g.ProxyFns, "}")
 	g.P(g.ProxyFns, "")
 }
 
-// generateClientFns generates the helper functions to instantiate a slice of service oriented client connections.
-func (g *proxy) generateClientFns(serviceName, pkgName string) {
-	fullServName := serviceName
-	if pkgName != "" {
-		fullServName = pkgName + "." + fullServName
-	}
+// generateClientFns generates the helper functions to instantiate a slice of
+// service oriented client connections. The connections come from the pool of
+// the proxy and are handed back by the returned func, each target being
+// dialed with its own credentials when the proxy provides them. The dial
+// options of the proxy apply to the connections dialed by the pool, the call
+// options to every call made through the clients. The client contexts are
+// derived from the incoming one so the calls to the targets get its deadline
+// and are cancelled along with it.
+func (g *proxy) generateClientFns(service *protogen.Service) {
+	serviceName := service.GoName
+
 	g.P(g.Clients, "")
-	g.P(g.Clients, "func create"+serviceName+"Client(targets []string, creds credentials.TransportCredentials, proxyMd metadata.MD) ([]*proxy"+serviceName+"Client ,error){")
-	g.P(g.Clients, "var errors *go_multierror.Error")
+	g.P(g.Clients, "func (p *"+g.proxyName()+") create"+serviceName+"Client(",
+		"ctx ", contextPackage.Ident("Context"), ", ",
+		"targets []string, ",
+		"creds ", credentialsPackage.Ident("TransportCredentials"), ", ",
+		"proxyMd ", metadataPackage.Ident("MD"), ", ",
+		"opts []", grpcPackage.Ident("CallOption"),
+		") ([]*proxy"+serviceName+"Client, func(), error){")
+	g.P(g.Clients, "var errs []error")
 	g.P(g.Clients, "clients := make([]*proxy"+serviceName+"Client, 0, len(targets))")
+	g.P(g.Clients, "releases := make([]func(), 0, len(targets))")
 	g.P(g.Clients, "for _, target := range targets {")
 	g.P(g.Clients, "c := &proxy"+serviceName+"Client{")
-	g.P(g.Clients, "// TODO change the context to be more useful ( ex cancelable )")
-	g.P(g.Clients, "Context: metadata.NewOutgoingContext(context.Background(), proxyMd),")
+	g.P(g.Clients, "Context: ", metadataPackage.Ident("NewOutgoingContext"), "(ctx, proxyMd),")
 	g.P(g.Clients, "Target:  target,")
+	g.P(g.Clients, "DialOpts: p.DialOptions,")
+	g.P(g.Clients, "CallOpts: opts,")
+	g.P(g.Clients, "}")
+	g.P(g.Clients, "dialTarget, err := ", runtimePackage.Ident("DialTarget"), "(target, p.DefaultPort)")
+	g.P(g.Clients, "if err != nil {")
+	g.P(g.Clients, "errs = append(errs, &", runtimePackage.Ident("TargetError"), "{Target: target, Err: err})")
+	g.P(g.Clients, "continue")
 	g.P(g.Clients, "}")
-	g.P(g.Clients, "// TODO: i think we potentially leak a client here,")
-	g.P(g.Clients, "// we should close the request // cancel the context if it errors")
-	g.P(g.Clients, "// Explicitly set OSD port")
-	g.P(g.Clients, "conn, err := grpc.Dial(fmt.Sprintf(\"%s:%d\", target, 50000), grpc.WithTransportCredentials(creds))")
+	g.P(g.Clients, "targetCreds, err := ", runtimePackage.Ident("TargetCredentials"), "(target, creds, p.CredentialsFor, p.VerifyTargetName)")
 	g.P(g.Clients, "if err != nil {")
-	g.P(g.Clients, "// TODO: probably worth wrapping err to add some context about the target")
-	g.P(g.Clients, "errors = go_multierror.Append(errors, err)")
+	g.P(g.Clients, "errs = append(errs, &", runtimePackage.Ident("TargetError"), "{Target: target, Err: err})")
 	g.P(g.Clients, "continue")
 	g.P(g.Clients, "}")
-	g.P(g.Clients, "c.Conn = "+pkgName+".New"+serviceName+"Client(conn)")
+	g.P(g.Clients, "conn, release, err := p.Pool.Get(p.Dialer, dialTarget, targetCreds, c.DialOpts...)")
+	g.P(g.Clients, "if err != nil {")
+	g.P(g.Clients, "errs = append(errs, &", runtimePackage.Ident("TargetError"), "{Target: target, Err: err})")
+	g.P(g.Clients, "continue")
+	g.P(g.Clients, "}")
+	g.P(g.Clients, "releases = append(releases, release)")
+	g.P(g.Clients, "c.Conn = ", g.serviceIdent(service, "New", "Client"), "(conn)")
 	g.P(g.Clients, "clients = append(clients, c)")
 	g.P(g.Clients, "}")
-	g.P(g.Clients, "return clients, errors.ErrorOrNil()")
+	g.P(g.Clients, "return clients, func() {")
+	g.P(g.Clients, "for _, release := range releases {")
+	g.P(g.Clients, "release()")
+	g.P(g.Clients, "}")
+	g.P(g.Clients, "}, ", runtimePackage.Ident("JoinErrors"), "(errs...)")
 	g.P(g.Clients, "}")
 }
@@ -5,125 +5,206 @@
 package proxy
 
 import (
-	"fmt"
-	"strings"
+	"google.golang.org/protobuf/compiler/protogen"
 
-	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
-	"github.com/golang/protobuf/protoc-gen-go/generator"
+	options "github.com/talos-systems/protoc-gen-proxy/proxy"
 )
 
 // generateStreamProxyRouter creates the routing part of the proxy. That is it
 // enables us to map the incoming grpc method to the function/client call
 // so we can properly call the proper rpc endpoint.
-func (g *proxy) generateStreamProxyRouter(serviceName string) {
-
-	var args strings.Builder
-	args.WriteString("(")
-	args.WriteString("ss " + grpcPkg + ".ServerStream, ")
-	args.WriteString("method string, ")
-	args.WriteString("creds credentials.TransportCredentials, ")
-	args.WriteString("srv interface{}, ")
-	args.WriteString("opts ..." + grpcPkg + ".CallOption")
-	args.WriteString(")")
-
-	var returns strings.Builder
-	returns.WriteString("error")
+func (g *proxy) generateStreamProxyRouter() {
+	g.gen.P("func (p *"+g.proxyName()+") StreamProxy(",
+		"ss ", grpcPackage.Ident("ServerStream"), ", ",
+		"method string, ",
+		"creds ", credentialsPackage.Ident("TransportCredentials"), ", ",
+		"srv interface{}, ",
+		"opts ...", grpcPackage.Ident("CallOption"),
+		") error {")
+
+	// Nothing to route
+	if g.StreamProxySwitch.Len() == 0 {
+		g.gen.P("return ", runtimePackage.Ident("ErrUnknownMethod"))
+		g.gen.P("}")
+		g.gen.P("")
+
+		return
+	}
 
-	g.gen.P("func (p *" + generator.CamelCase(serviceName+"_proxy") + ") StreamProxy " + args.String() + returns.String() + "{")
 	g.gen.P("var (")
 	g.gen.P("err error")
-	g.gen.P("errors *go_multierror.Error")
-	g.gen.P("ok bool")
 	g.gen.P("targets []string")
+	g.gen.P("in interface{}")
 	g.gen.P(")")
 	g.gen.P("")
 
 	// Parse targets from incoming metadata/context
-	g.gen.P("md, _ := metadata.FromIncomingContext(ss.Context())")
-	g.gen.P("// default to target node specified in config or on cli")
-	g.gen.P("if targets, ok = md[\"targets\"]; !ok {")
-	g.gen.P("targets = md[\":authority\"]")
+	g.gen.P("md, _ := ", metadataPackage.Ident("FromIncomingContext"), "(ss.Context())")
+	g.gen.P("// the requests without targets are meant for the proxy node itself, they")
+	g.gen.P("// are rejected rather than proxied to its own authority")
+	g.gen.P("targets = md[\"targets\"]")
+	g.gen.P("if p.Resolver != nil {")
+	g.gen.P("if targets, err = p.Resolver.Resolve(ss.Context(), targets); err != nil {")
+	g.gen.P("return err")
+	g.gen.P("}")
+	g.gen.P("}")
+	g.gen.P("// the targets are checked before any of them gets dialed")
+	g.gen.P("if err = p.TargetPolicy.Check(ss.Context(), method, targets); err != nil {")
+	g.gen.P("return err")
 	g.gen.P("}")
-	g.gen.P("// Can discuss more on how to handle merging multiple streams later")
-	g.gen.P("// but for now, ensure we only deal with a single target")
-	g.gen.P("if len(targets) > 1 {")
-	g.gen.P("targets = targets[:1]")
+
+	if g.StreamRequests.Len() > 0 {
+		g.gen.P("// the request of the server streams is received first for the")
+		g.gen.P("// authorizer to see it")
+		g.gen.P("switch method {")
+		g.gen.P(g.StreamRequests.String())
+		g.gen.P("}")
+	}
+
+	g.gen.P("if targets, err = ", runtimePackage.Ident("Authorize"), "(ss.Context(), p.Authorizer, method, methodRoles(method), targets, in); err != nil {")
+	g.gen.P("return err")
+	g.gen.P("}")
+	g.gen.P("if len(targets) == 0 {")
+	g.gen.P("return ", statusPackage.Ident("Error"), "(", codesPackage.Ident("InvalidArgument"), ", \"no targets to proxy to\")")
 	g.gen.P("}")
 	g.gen.P("")
 
 	// Set up client connections
-	g.gen.P("proxyMd := metadata.New(make(map[string]string))")
-	g.gen.P("proxyMd.Set(\"proxyfrom\", md[\":authority\"]...)")
+	g.gen.P("proxyMd := ", runtimePackage.Ident("ForwardMetadata"), "(md, p.ForwardedMetadata)")
+	g.gen.P(runtimePackage.Ident("SetVia"), "(proxyMd, md, p.Name)")
 	g.gen.P("")
 
 	// Handle routes
 	g.gen.P("switch method {")
 	g.gen.P(g.StreamProxySwitch.String())
+	g.gen.P("default:")
+	g.gen.P("return ", runtimePackage.Ident("ErrUnknownMethod"))
 	g.gen.P("}")
 	g.gen.P("")
-	g.gen.P("if err != nil {")
-	g.gen.P("errors = go_multierror.Append(errors, err)")
-	g.gen.P("}")
-	g.gen.P("return errors.ErrorOrNil()")
+	g.gen.P("return err")
 	g.gen.P("}")
 }
 
-func (g *proxy) generateStreamSwitchStatement(serviceName, pkgName string, methods []*pb.MethodDescriptorProto) {
-	for _, method := range methods {
+func (g *proxy) generateStreamSwitchStatement(service *protogen.Service) {
+	for _, method := range service.Methods {
 		// Only handle streaming methods
 		switch {
-		case method.GetServerStreaming():
-		case method.GetClientStreaming():
+		case method.Desc.IsStreamingServer():
+		case method.Desc.IsStreamingClient():
 		default:
 			continue
 		}
 
-		// skip support for deprecated methods
-		if method.GetOptions().GetDeprecated() {
+		mode := methodMode(service, method)
+
+		// the interceptors check the roles of every method, whether it gets
+		// proxied or handled locally
+		g.collectRoles(service, method)
+
+		switch mode {
+		case options.Mode_FANOUT, options.Mode_SINGLE:
+		case options.Mode_LOCAL_ONLY:
+			g.localMethods = append(g.localMethods, fullMethodName(service, method))
+
+			continue
+		default:
 			continue
 		}
 
-		fullServName := serviceName
-		if pkgName != "" {
-			fullServName = pkgName + "." + fullServName
+		g.P(g.StreamProxySwitch, "case \""+fullMethodName(service, method)+"\":")
+		g.routedMethods = append(g.routedMethods, fullMethodName(service, method))
+		g.generateCallOptions(g.StreamProxySwitch, service, method)
+
+		// Only server streams get merged, the inbound messages of client
+		// and bidi streams can't be split between several targets.
+		if mode == options.Mode_SINGLE || method.Desc.IsStreamingClient() {
+			g.P(g.StreamProxySwitch, "if len(targets) > 1 {")
+			g.P(g.StreamProxySwitch, "targets = targets[:1]")
+			g.P(g.StreamProxySwitch, "}")
+		}
+
+		if !method.Desc.IsStreamingClient() {
+			g.P(g.StreamRequests, "case \""+fullMethodName(service, method)+"\":")
+			g.P(g.StreamRequests, "m := new("+g.typeName(method.Input)+")")
+			g.P(g.StreamRequests, "if err = ss.RecvMsg(m); err != nil {")
+			g.P(g.StreamRequests, "return err")
+			g.P(g.StreamRequests, "}")
+			g.P(g.StreamRequests, "in = m")
+
+			g.generateStreamFanIn(service, method)
+
+			continue
 		}
-		sname := fmt.Sprintf("/%s/%s", fullServName, method.GetName())
-		g.P(g.StreamProxySwitch, "case \""+sname+"\":")
+
 		g.P(g.StreamProxySwitch, "// Initialize target clients")
-		g.P(g.StreamProxySwitch, "clients, err := create"+serviceName+"Client(targets, creds, proxyMd)")
-		g.P(g.StreamProxySwitch, "if err != nil {")
-		g.P(g.StreamProxySwitch, "break")
+		g.P(g.StreamProxySwitch, "clients, release, dialErr := p.create"+service.GoName+"Client(ss.Context(), targets, creds, proxyMd, opts)")
+		g.P(g.StreamProxySwitch, "defer release()")
+		g.P(g.StreamProxySwitch, "if dialErr != nil {")
+		g.P(g.StreamProxySwitch, "return dialErr")
 		g.P(g.StreamProxySwitch, "}")
 
-		g.P(g.StreamProxySwitch, "m := new("+g.typeName(method.GetInputType())+")")
-		g.P(g.StreamProxySwitch, "if err := ss.RecvMsg(m); err != nil {")
+		if method.Desc.IsStreamingServer() {
+			g.P(g.StreamProxySwitch, "ctx, cancel := ", contextPackage.Ident("WithCancel"), "(clients[0].Context)")
+			g.P(g.StreamProxySwitch, "defer cancel()")
+			g.P(g.StreamProxySwitch, "clientStream, err := clients[0].Conn."+method.GoName+"(ctx, clients[0].CallOpts...)")
+			g.P(g.StreamProxySwitch, "if err != nil {")
+			g.P(g.StreamProxySwitch, "return err")
+			g.P(g.StreamProxySwitch, "}")
+			g.P(g.StreamProxySwitch, "return copyBidiStream("+g.newMsgFunc(method.Input)+", "+g.newMsgFunc(method.Output)+", ss, clientStream, cancel)")
+
+			continue
+		}
+
+		g.P(g.StreamProxySwitch, "clientStream, err := clients[0].Conn."+method.GoName+"(clients[0].Context, clients[0].CallOpts...)")
+		g.P(g.StreamProxySwitch, "if err != nil {")
 		g.P(g.StreamProxySwitch, "return err")
 		g.P(g.StreamProxySwitch, "}")
-
-		g.P(g.StreamProxySwitch, "// artificially limit this to only the first client/target until")
-		g.P(g.StreamProxySwitch, "// we get multi-stream stuff sorted")
-		//	g.P(g.StreamProxySwitch, "outgoingContext := metadata.NewOutgoingContext(ss.Context(), proxyMd)")
-		g.P(g.StreamProxySwitch, "clientStream, err := clients[0].Conn."+method.GetName()+"(clients[0].Context, m)")
+		g.P(g.StreamProxySwitch, "if err = copyServerClient("+g.newMsgFunc(method.Input)+", ss, clientStream); err != nil {")
+		g.P(g.StreamProxySwitch, "return err")
+		g.P(g.StreamProxySwitch, "}")
+		g.P(g.StreamProxySwitch, "resp, err := clientStream.CloseAndRecv()")
 		g.P(g.StreamProxySwitch, "if err != nil {")
 		g.P(g.StreamProxySwitch, "return err")
 		g.P(g.StreamProxySwitch, "}")
-		g.P(g.StreamProxySwitch, "var msg "+g.typeName(method.GetOutputType()))
-		g.P(g.StreamProxySwitch, "return copyClientServer(&msg, clientStream, ss.(grpc.ServerStream))")
-
-		/*
-			var fnArgs strings.Builder
-			fnArgs.WriteString("(")
-			fnArgs.WriteString("clients, ")
-			fnArgs.WriteString("in, ")
-			fnArgs.WriteString("proxy" + generator.CamelCase(method.GetName()))
-			fnArgs.WriteString(")")
-
-			g.P(g.StreamProxySwitch, "resp := &"+g.typeName(method.GetOutputType())+"{}")
-			g.P(g.StreamProxySwitch, "msgs, err = proxy"+serviceName+"Runner"+fnArgs.String())
-			g.P(g.StreamProxySwitch, "for _, msg := range msgs {")
-			g.P(g.StreamProxySwitch, "resp.Response = append(resp.Response, msg.(*"+g.typeName(method.GetOutputType())+").Response[0])")
-			g.P(g.StreamProxySwitch, "}")
-			g.P(g.StreamProxySwitch, "response = resp")
-		*/
+		g.P(g.StreamProxySwitch, "return ss.SendMsg(resp)")
 	}
 }
+
+// generateStreamFanIn opens the server stream on every target and merges
+// them onto the incoming stream. The targets which can't be dialed or fail
+// midway get reported along with the other errors once the healthy streams
+// are done.
+func (g *proxy) generateStreamFanIn(service *protogen.Service, method *protogen.Method) {
+	g.P(g.StreamProxySwitch, "// Initialize target clients")
+	g.P(g.StreamProxySwitch, "clients, release, dialErr := p.create"+service.GoName+"Client(ss.Context(), targets, creds, proxyMd, opts)")
+	g.P(g.StreamProxySwitch, "defer release()")
+	g.P(g.StreamProxySwitch, "if len(clients) == 0 {")
+	g.P(g.StreamProxySwitch, "err = dialErr")
+	g.P(g.StreamProxySwitch, "break")
+	g.P(g.StreamProxySwitch, "}")
+	g.P(g.StreamProxySwitch, "m := in.(*"+g.typeName(method.Input)+")")
+	g.P(g.StreamProxySwitch, "sources := make([]", runtimePackage.Ident("StreamSource"), ", 0, len(clients))")
+	g.P(g.StreamProxySwitch, "for _, client := range clients {")
+	g.P(g.StreamProxySwitch, "client := client")
+	g.P(g.StreamProxySwitch, "sources = append(sources, ", runtimePackage.Ident("StreamSource"), "{")
+	g.P(g.StreamProxySwitch, "Target: client.Target,")
+	g.P(g.StreamProxySwitch, "Context: client.Context,")
+	g.P(g.StreamProxySwitch, "Open: func(ctx ", contextPackage.Ident("Context"), ") (", grpcPackage.Ident("ClientStream"), ", error) {")
+	g.P(g.StreamProxySwitch, "return client.Conn."+method.GoName+"(ctx, m, client.CallOpts...)")
+	g.P(g.StreamProxySwitch, "},")
+	g.P(g.StreamProxySwitch, "})")
+	g.P(g.StreamProxySwitch, "}")
+
+	newMsg := "func() " + g.gen.QualifiedGoIdent(protoPackage.Ident("Message")) + " { return new(" + g.typeName(method.Output) + ") }"
+
+	tag := "nil"
+
+	if agg := g.aggregations[method]; agg != nil && agg.metadata != nil {
+		tag = "func(msg " + g.gen.QualifiedGoIdent(protoPackage.Ident("Message")) + ", target string) {\n" +
+			"m := msg.(*" + g.typeName(method.Output) + ")\n" +
+			g.tagMetadata(agg, "m", "target") + "\n" +
+			"}"
+	}
+
+	g.P(g.StreamProxySwitch, "err = ", runtimePackage.Ident("JoinErrors"), "(dialErr, ", runtimePackage.Ident("MergeStreams"), "(ss, sources, "+newMsg+", "+tag+"))")
+}
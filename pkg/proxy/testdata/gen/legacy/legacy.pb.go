@@ -0,0 +1,241 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: legacy/legacy.proto
+
+package legacy
+
+import (
+	common "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/common"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Status struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metadata      *common.NodeMetadata   `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	State         string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	mi := &file_legacy_legacy_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_legacy_legacy_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_legacy_legacy_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Status) GetMetadata() *common.NodeMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Status) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+type StatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Response      []*Status              `protobuf:"bytes,1,rep,name=response,proto3" json:"response,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_legacy_legacy_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_legacy_legacy_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_legacy_legacy_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StatusResponse) GetResponse() []*Status {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+type Chunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	mi := &file_legacy_legacy_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_legacy_legacy_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_legacy_legacy_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Chunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_legacy_legacy_proto protoreflect.FileDescriptor
+
+const file_legacy_legacy_proto_rawDesc = "" +
+	"\n" +
+	"\x13legacy/legacy.proto\x12\x06legacy\x1a\x1bgoogle/protobuf/empty.proto\x1a\x13common/common.proto\"P\n" +
+	"\x06Status\x120\n" +
+	"\bmetadata\x18\x01 \x01(\v2\x14.common.NodeMetadataR\bmetadata\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\"<\n" +
+	"\x0eStatusResponse\x12*\n" +
+	"\bresponse\x18\x01 \x03(\v2\x0e.legacy.StatusR\bresponse\"\x1b\n" +
+	"\x05Chunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data2\xb6\x01\n" +
+	"\x06Legacy\x128\n" +
+	"\x06Status\x12\x16.google.protobuf.Empty\x1a\x16.legacy.StatusResponse\x12<\n" +
+	"\x05Reset\x12\x16.google.protobuf.Empty\x1a\x16.legacy.StatusResponse\"\x03\x88\x02\x01\x124\n" +
+	"\x04Dump\x12\x16.google.protobuf.Empty\x1a\r.legacy.Chunk\"\x03\x88\x02\x010\x01BIZGgithub.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/legacyb\x06proto3"
+
+var (
+	file_legacy_legacy_proto_rawDescOnce sync.Once
+	file_legacy_legacy_proto_rawDescData []byte
+)
+
+func file_legacy_legacy_proto_rawDescGZIP() []byte {
+	file_legacy_legacy_proto_rawDescOnce.Do(func() {
+		file_legacy_legacy_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_legacy_legacy_proto_rawDesc), len(file_legacy_legacy_proto_rawDesc)))
+	})
+	return file_legacy_legacy_proto_rawDescData
+}
+
+var file_legacy_legacy_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_legacy_legacy_proto_goTypes = []any{
+	(*Status)(nil),              // 0: legacy.Status
+	(*StatusResponse)(nil),      // 1: legacy.StatusResponse
+	(*Chunk)(nil),               // 2: legacy.Chunk
+	(*common.NodeMetadata)(nil), // 3: common.NodeMetadata
+	(*emptypb.Empty)(nil),       // 4: google.protobuf.Empty
+}
+var file_legacy_legacy_proto_depIdxs = []int32{
+	3, // 0: legacy.Status.metadata:type_name -> common.NodeMetadata
+	0, // 1: legacy.StatusResponse.response:type_name -> legacy.Status
+	4, // 2: legacy.Legacy.Status:input_type -> google.protobuf.Empty
+	4, // 3: legacy.Legacy.Reset:input_type -> google.protobuf.Empty
+	4, // 4: legacy.Legacy.Dump:input_type -> google.protobuf.Empty
+	1, // 5: legacy.Legacy.Status:output_type -> legacy.StatusResponse
+	1, // 6: legacy.Legacy.Reset:output_type -> legacy.StatusResponse
+	2, // 7: legacy.Legacy.Dump:output_type -> legacy.Chunk
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_legacy_legacy_proto_init() }
+func file_legacy_legacy_proto_init() {
+	if File_legacy_legacy_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_legacy_legacy_proto_rawDesc), len(file_legacy_legacy_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_legacy_legacy_proto_goTypes,
+		DependencyIndexes: file_legacy_legacy_proto_depIdxs,
+		MessageInfos:      file_legacy_legacy_proto_msgTypes,
+	}.Build()
+	File_legacy_legacy_proto = out.File
+	file_legacy_legacy_proto_goTypes = nil
+	file_legacy_legacy_proto_depIdxs = nil
+}
@@ -0,0 +1,340 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: logs/logs.proto
+
+package logs
+
+import (
+	common "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/common"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TailRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Follow        bool                   `protobuf:"varint,2,opt,name=follow,proto3" json:"follow,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TailRequest) Reset() {
+	*x = TailRequest{}
+	mi := &file_logs_logs_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TailRequest) ProtoMessage() {}
+
+func (x *TailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logs_logs_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TailRequest.ProtoReflect.Descriptor instead.
+func (*TailRequest) Descriptor() ([]byte, []int) {
+	return file_logs_logs_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TailRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *TailRequest) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+type LogEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metadata      *common.NodeMetadata   `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Line          []byte                 `protobuf:"bytes,2,opt,name=line,proto3" json:"line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogEntry) Reset() {
+	*x = LogEntry{}
+	mi := &file_logs_logs_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEntry) ProtoMessage() {}
+
+func (x *LogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_logs_logs_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
+func (*LogEntry) Descriptor() ([]byte, []int) {
+	return file_logs_logs_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LogEntry) GetMetadata() *common.NodeMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *LogEntry) GetLine() []byte {
+	if x != nil {
+		return x.Line
+	}
+	return nil
+}
+
+type SourcesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SourcesRequest) Reset() {
+	*x = SourcesRequest{}
+	mi := &file_logs_logs_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SourcesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SourcesRequest) ProtoMessage() {}
+
+func (x *SourcesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logs_logs_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SourcesRequest.ProtoReflect.Descriptor instead.
+func (*SourcesRequest) Descriptor() ([]byte, []int) {
+	return file_logs_logs_proto_rawDescGZIP(), []int{2}
+}
+
+type Sources struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metadata      *common.NodeMetadata   `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Sources       []string               `protobuf:"bytes,2,rep,name=sources,proto3" json:"sources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sources) Reset() {
+	*x = Sources{}
+	mi := &file_logs_logs_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sources) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sources) ProtoMessage() {}
+
+func (x *Sources) ProtoReflect() protoreflect.Message {
+	mi := &file_logs_logs_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sources.ProtoReflect.Descriptor instead.
+func (*Sources) Descriptor() ([]byte, []int) {
+	return file_logs_logs_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Sources) GetMetadata() *common.NodeMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Sources) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+type SourcesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Response      []*Sources             `protobuf:"bytes,1,rep,name=response,proto3" json:"response,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SourcesResponse) Reset() {
+	*x = SourcesResponse{}
+	mi := &file_logs_logs_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SourcesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SourcesResponse) ProtoMessage() {}
+
+func (x *SourcesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_logs_logs_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SourcesResponse.ProtoReflect.Descriptor instead.
+func (*SourcesResponse) Descriptor() ([]byte, []int) {
+	return file_logs_logs_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SourcesResponse) GetResponse() []*Sources {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+var File_logs_logs_proto protoreflect.FileDescriptor
+
+const file_logs_logs_proto_rawDesc = "" +
+	"\n" +
+	"\x0flogs/logs.proto\x12\x04logs\x1a\x13common/common.proto\"=\n" +
+	"\vTailRequest\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x16\n" +
+	"\x06follow\x18\x02 \x01(\bR\x06follow\"P\n" +
+	"\bLogEntry\x120\n" +
+	"\bmetadata\x18\x01 \x01(\v2\x14.common.NodeMetadataR\bmetadata\x12\x12\n" +
+	"\x04line\x18\x02 \x01(\fR\x04line\"\x10\n" +
+	"\x0eSourcesRequest\"U\n" +
+	"\aSources\x120\n" +
+	"\bmetadata\x18\x01 \x01(\v2\x14.common.NodeMetadataR\bmetadata\x12\x18\n" +
+	"\asources\x18\x02 \x03(\tR\asources\"<\n" +
+	"\x0fSourcesResponse\x12)\n" +
+	"\bresponse\x18\x01 \x03(\v2\r.logs.SourcesR\bresponse2k\n" +
+	"\x04Logs\x12+\n" +
+	"\x04Tail\x12\x11.logs.TailRequest\x1a\x0e.logs.LogEntry0\x01\x126\n" +
+	"\aSources\x12\x14.logs.SourcesRequest\x1a\x15.logs.SourcesResponseBGZEgithub.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/logsb\x06proto3"
+
+var (
+	file_logs_logs_proto_rawDescOnce sync.Once
+	file_logs_logs_proto_rawDescData []byte
+)
+
+func file_logs_logs_proto_rawDescGZIP() []byte {
+	file_logs_logs_proto_rawDescOnce.Do(func() {
+		file_logs_logs_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_logs_logs_proto_rawDesc), len(file_logs_logs_proto_rawDesc)))
+	})
+	return file_logs_logs_proto_rawDescData
+}
+
+var file_logs_logs_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_logs_logs_proto_goTypes = []any{
+	(*TailRequest)(nil),         // 0: logs.TailRequest
+	(*LogEntry)(nil),            // 1: logs.LogEntry
+	(*SourcesRequest)(nil),      // 2: logs.SourcesRequest
+	(*Sources)(nil),             // 3: logs.Sources
+	(*SourcesResponse)(nil),     // 4: logs.SourcesResponse
+	(*common.NodeMetadata)(nil), // 5: common.NodeMetadata
+}
+var file_logs_logs_proto_depIdxs = []int32{
+	5, // 0: logs.LogEntry.metadata:type_name -> common.NodeMetadata
+	5, // 1: logs.Sources.metadata:type_name -> common.NodeMetadata
+	3, // 2: logs.SourcesResponse.response:type_name -> logs.Sources
+	0, // 3: logs.Logs.Tail:input_type -> logs.TailRequest
+	2, // 4: logs.Logs.Sources:input_type -> logs.SourcesRequest
+	1, // 5: logs.Logs.Tail:output_type -> logs.LogEntry
+	4, // 6: logs.Logs.Sources:output_type -> logs.SourcesResponse
+	5, // [5:7] is the sub-list for method output_type
+	3, // [3:5] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_logs_logs_proto_init() }
+func file_logs_logs_proto_init() {
+	if File_logs_logs_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_logs_logs_proto_rawDesc), len(file_logs_logs_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_logs_logs_proto_goTypes,
+		DependencyIndexes: file_logs_logs_proto_depIdxs,
+		MessageInfos:      file_logs_logs_proto_msgTypes,
+	}.Build()
+	File_logs_logs_proto = out.File
+	file_logs_logs_proto_goTypes = nil
+	file_logs_logs_proto_depIdxs = nil
+}
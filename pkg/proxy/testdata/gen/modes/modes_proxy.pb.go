@@ -0,0 +1,965 @@
+// Code generated by protoc-gen-proxy. DO NOT EDIT.
+// source: modes.proto
+
+package modes
+
+import (
+	context "context"
+	common "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/common"
+	routing "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/routing"
+	runtime "github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	credentials "google.golang.org/grpc/credentials"
+	metadata "google.golang.org/grpc/metadata"
+	status "google.golang.org/grpc/status"
+	proto "google.golang.org/protobuf/proto"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	io "io"
+	time "time"
+)
+
+type ModesProxy struct {
+	Provider runtime.CertificateProvider
+	// Credentials caches the credentials built from the Provider until it
+	// signals a rotation, a nil Credentials builds them on each request.
+	Credentials *runtime.CredentialsCache
+	// CredentialsFor returns the credentials to dial a target with, e.g. to
+	// trust the CA of its cluster. The targets it returns nil credentials
+	// for, or all of them when unset, are dialed with the Credentials.
+	CredentialsFor runtime.CredentialsFunc
+	// VerifyTargetName requires the certificate of each target to be valid
+	// for the host it was requested as.
+	VerifyTargetName bool
+	// Name identifies the proxy in the route of the requests, it defaults to
+	// the authority of each request.
+	Name string
+	// MaxHops is the number of proxies a request may go through, the
+	// requests which went through as many get handled locally. Zero only
+	// lets a single proxy forward them.
+	MaxHops int
+	// DefaultPort is dialed when a target doesn't specify a port.
+	DefaultPort int
+	// Pool holds the connections to the targets, a nil Pool dials them on
+	// each request.
+	Pool *runtime.Pool
+	// DialOptions are applied when dialing the targets, e.g. keepalives,
+	// message sizes or a custom dialer. The pooled connections keep the
+	// options they were dialed with.
+	DialOptions []grpc.DialOption
+	// Dialer creates the connections to the targets, e.g. to in-memory
+	// servers in tests with a runtimetest.BufconnDialer. The targets are dialed
+	// over the network when unset.
+	Dialer runtime.Dialer
+	// Balancer spreads the methods dispatched to one of their targets, it
+	// tracks the calls in flight to each target.
+	Balancer *runtime.Balancer
+	// ForwardedMetadata lists the keys of the incoming metadata passed on to
+	// the targets.
+	ForwardedMetadata []string
+	// Resolver expands the logical targets of the requests, such as "all",
+	// into the addresses of the nodes. The targets are used as is when unset.
+	Resolver runtime.Resolver
+	// TargetPolicy restricts the targets the requests may ask for, any
+	// target is allowed when unset.
+	TargetPolicy *runtime.TargetPolicy
+	// Authorizer decides whether the requests get proxied and may narrow
+	// their targets, once the caller is checked against the roles of the
+	// method.
+	Authorizer runtime.Authorizer
+	// UnaryFallback and StreamFallback handle the methods of the proxied
+	// services the proxy doesn't route, those fail with Unimplemented when
+	// unset. runtime.LocalUnaryFallback and runtime.LocalStreamFallback
+	// hand them over to the local server.
+	UnaryFallback  grpc.UnaryServerInterceptor
+	StreamFallback grpc.StreamServerInterceptor
+}
+
+func NewModesProxy(provider runtime.CertificateProvider) *ModesProxy {
+	return &ModesProxy{
+		Provider:    provider,
+		Credentials: runtime.NewCredentialsCache(),
+		Pool:        runtime.NewPool(runtime.DefaultIdleTimeout),
+		Balancer:    runtime.NewBalancer(),
+	}
+}
+
+// Close closes the connections to the targets.
+func (p *ModesProxy) Close() error {
+	return p.Pool.Close()
+}
+
+func proxiedService(fullMethod string) bool {
+	switch runtime.ServiceName(fullMethod) {
+	case "routing.Routing":
+		return true
+	}
+	return false
+}
+
+func routedMethod(fullMethod string) bool {
+	switch fullMethod {
+	case "/routing.Routing/Fanout", "/routing.Routing/Single", "/routing.Routing/Stats", "/routing.Routing/Partial", "/routing.Routing/Any", "/routing.Routing/Quorum", "/routing.Routing/OneOf", "/routing.Routing/Majority":
+		return true
+	}
+	return false
+}
+
+func methodRoles(fullMethod string) []string {
+	switch fullMethod {
+	case "/routing.Routing/Single":
+		return []string{"os:admin", "os:operator"}
+
+	}
+	return nil
+}
+
+func (p *ModesProxy) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if !proxiedService(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		// the roles are checked whether the request is proxied or not, the
+		// route metadata being set by the callers
+		if err := runtime.CheckRoles(ctx, info.FullMethod, methodRoles(info.FullMethod)); err != nil {
+			return nil, err
+		}
+		forward, err := runtime.Forward(md, p.Name, p.MaxHops)
+		if err != nil {
+			return nil, err
+		}
+		if !forward {
+			return handler(ctx, req)
+		}
+		switch info.FullMethod {
+		case "/routing.Routing/Local", "/routing.Routing/Events":
+			return handler(ctx, req)
+		}
+		if !routedMethod(info.FullMethod) {
+			if p.UnaryFallback != nil {
+				return p.UnaryFallback(ctx, req, info, handler)
+			}
+			return nil, runtime.ErrUnknownMethod
+		}
+		creds, err := p.Credentials.Get(p.Provider)
+		if err != nil {
+			return nil, err
+		}
+		return p.UnaryProxy(ctx, info.FullMethod, creds, req)
+	}
+}
+
+func (p *ModesProxy) UnaryProxy(ctx context.Context, method string, creds credentials.TransportCredentials, in interface{}, opts ...grpc.CallOption) (proto.Message, error) {
+	var (
+		err      error
+		msgs     []proto.Message
+		response proto.Message
+		targets  []string
+	)
+	md, _ := metadata.FromIncomingContext(ctx)
+	// the requests without targets are meant for the proxy node itself, they
+	// are rejected rather than proxied to its own authority
+	targets = md["targets"]
+	if p.Resolver != nil {
+		if targets, err = p.Resolver.Resolve(ctx, targets); err != nil {
+			return nil, err
+		}
+	}
+	// the targets are checked before any of them gets dialed
+	if err = p.TargetPolicy.Check(ctx, method, targets); err != nil {
+		return nil, err
+	}
+	if targets, err = runtime.Authorize(ctx, p.Authorizer, method, methodRoles(method), targets, in); err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no targets to proxy to")
+	}
+	proxyMd := runtime.ForwardMetadata(md, p.ForwardedMetadata)
+	runtime.SetVia(proxyMd, md, p.Name)
+
+	switch method {
+	case "/routing.Routing/Fanout":
+		execution := runtime.Execution{Balancer: p.Balancer}
+		if err = execution.ApplyMetadata(md); err != nil {
+			break
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createRoutingClient(ctx, targets, creds, proxyMd, opts)
+		defer release()
+		resp := &routing.FanoutResponse{}
+		msgs, err = proxyRoutingRunner(metadata.NewOutgoingContext(ctx, proxyMd), clients, in, proxyFanout, execution)
+		for _, msg := range msgs {
+			resp.Messages = append(resp.Messages, msg.(*routing.FanoutResponse).Messages...)
+		}
+		response = resp
+		// the targets which couldn't be dialed failed as well
+		err = runtime.JoinErrors(dialErr, err)
+	case "/routing.Routing/Single":
+		// Only the first target gets the request
+		if len(targets) > 1 {
+			targets = targets[:1]
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createRoutingClient(ctx, targets, creds, proxyMd, opts)
+		defer release()
+		if dialErr != nil {
+			err = dialErr
+			break
+		}
+		response, err = clients[0].Conn.Single(clients[0].Context, in.(*emptypb.Empty), clients[0].CallOpts...)
+	case "/routing.Routing/Stats":
+		execution := runtime.Execution{MaxConcurrency: 2, TargetTimeout: 1500 * time.Millisecond, Balancer: p.Balancer}
+		if err = execution.ApplyMetadata(md); err != nil {
+			break
+		}
+		opts = append(opts[:len(opts):len(opts)], grpc.MaxCallRecvMsgSize(16777216), grpc.WaitForReady(true))
+		// Initialize target clients
+		clients, release, dialErr := p.createRoutingClient(ctx, targets, creds, proxyMd, opts)
+		defer release()
+		resp := &routing.StatsResponse{}
+		msgs, err = proxyRoutingRunner(metadata.NewOutgoingContext(ctx, proxyMd), clients, in, proxyStats, execution)
+		for _, msg := range msgs {
+			resp.Stats = append(resp.Stats, msg.(*routing.StatsResponse).Stats...)
+		}
+		response = resp
+		// the targets which couldn't be dialed failed as well
+		err = runtime.JoinErrors(dialErr, err)
+	case "/routing.Routing/Partial":
+		execution := runtime.Execution{BatchSize: 1, StopOnError: true, Balancer: p.Balancer}
+		if err = execution.ApplyMetadata(md); err != nil {
+			break
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createRoutingClient(ctx, targets, creds, proxyMd, opts)
+		defer release()
+		resp := &routing.FanoutResponse{}
+		msgs, err = proxyRoutingRunner(metadata.NewOutgoingContext(ctx, proxyMd), clients, in, proxyPartial, execution)
+		for _, msg := range msgs {
+			resp.Messages = append(resp.Messages, msg.(*routing.FanoutResponse).Messages...)
+		}
+		for _, targetErr := range runtime.TargetErrors(runtime.JoinErrors(dialErr, err)) {
+			resp.Messages = append(resp.Messages, &routing.Reply{
+				Metadata: &common.NodeMetadata{
+					Hostname: targetErr.Target,
+					Error:    status.Convert(targetErr.Err).Message(),
+					Code:     int32(status.Code(targetErr.Err)),
+				},
+			})
+		}
+		response, err = resp, nil
+	case "/routing.Routing/Any":
+		execution := runtime.Execution{Dispatch: runtime.DispatchAny, Balancer: p.Balancer}
+		if err = execution.ApplyMetadata(md); err != nil {
+			break
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createRoutingClient(ctx, targets, creds, proxyMd, opts)
+		defer release()
+		resp := &routing.FanoutResponse{}
+		execution.Unreachable = len(runtime.TargetErrors(dialErr))
+		msgs, err = proxyRoutingRunner(metadata.NewOutgoingContext(ctx, proxyMd), clients, in, proxyAny, execution)
+		if err == nil {
+			dialErr = nil
+		}
+		for _, msg := range msgs {
+			resp.Messages = append(resp.Messages, msg.(*routing.FanoutResponse).Messages...)
+		}
+		response = resp
+		// the targets which couldn't be dialed failed as well
+		err = runtime.JoinErrors(dialErr, err)
+	case "/routing.Routing/Quorum":
+		execution := runtime.Execution{Dispatch: runtime.DispatchQuorum, Balancer: p.Balancer}
+		if err = execution.ApplyMetadata(md); err != nil {
+			break
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createRoutingClient(ctx, targets, creds, proxyMd, opts)
+		defer release()
+		resp := &routing.FanoutResponse{}
+		execution.Unreachable = len(runtime.TargetErrors(dialErr))
+		msgs, err = proxyRoutingRunner(metadata.NewOutgoingContext(ctx, proxyMd), clients, in, proxyQuorum, execution)
+		if err == nil {
+			dialErr = nil
+		}
+		for _, msg := range msgs {
+			resp.Messages = append(resp.Messages, msg.(*routing.FanoutResponse).Messages...)
+		}
+		response = resp
+		// the targets which couldn't be dialed failed as well
+		err = runtime.JoinErrors(dialErr, err)
+	case "/routing.Routing/OneOf":
+		execution := runtime.Execution{Dispatch: runtime.DispatchOneOf, Balancing: runtime.LeastLoaded, Balancer: p.Balancer}
+		if err = execution.ApplyMetadata(md); err != nil {
+			break
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createRoutingClient(ctx, targets, creds, proxyMd, opts)
+		defer release()
+		resp := &routing.FanoutResponse{}
+		execution.Unreachable = len(runtime.TargetErrors(dialErr))
+		msgs, err = proxyRoutingRunner(metadata.NewOutgoingContext(ctx, proxyMd), clients, in, proxyOneOf, execution)
+		if err == nil {
+			dialErr = nil
+		}
+		for _, msg := range msgs {
+			resp.Messages = append(resp.Messages, msg.(*routing.FanoutResponse).Messages...)
+		}
+		response = resp
+		// the targets which couldn't be dialed failed as well
+		err = runtime.JoinErrors(dialErr, err)
+	case "/routing.Routing/Majority":
+		execution := runtime.Execution{Dispatch: runtime.DispatchQuorum, Balancer: p.Balancer}
+		if err = execution.ApplyMetadata(md); err != nil {
+			break
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createRoutingClient(ctx, targets, creds, proxyMd, opts)
+		defer release()
+		resp := &routing.FanoutResponse{}
+		execution.Unreachable = len(runtime.TargetErrors(dialErr))
+		msgs, err = proxyRoutingRunner(metadata.NewOutgoingContext(ctx, proxyMd), clients, in, proxyMajority, execution)
+		if err == nil {
+			dialErr = nil
+		}
+		if err != nil {
+			err = runtime.JoinErrors(dialErr, err)
+			break
+		}
+		for _, msg := range msgs {
+			resp.Messages = append(resp.Messages, msg.(*routing.FanoutResponse).Messages...)
+		}
+		for _, targetErr := range runtime.TargetErrors(runtime.JoinErrors(dialErr, err)) {
+			resp.Messages = append(resp.Messages, &routing.Reply{
+				Metadata: &common.NodeMetadata{
+					Hostname: targetErr.Target,
+					Error:    status.Convert(targetErr.Err).Message(),
+					Code:     int32(status.Code(targetErr.Err)),
+				},
+			})
+		}
+		response, err = resp, nil
+
+	default:
+		return nil, runtime.ErrUnknownMethod
+	}
+
+	return response, err
+}
+func copyClientServer(newMsg func() interface{}, client grpc.ClientStream, srv grpc.ServerStream) error {
+	for {
+		msg := newMsg()
+		err := client.RecvMsg(msg)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = srv.SendMsg(msg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyServerClient(newMsg func() interface{}, srv grpc.ServerStream, client grpc.ClientStream) error {
+	for {
+		msg := newMsg()
+		err := srv.RecvMsg(msg)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = client.SendMsg(msg)
+		if err == io.EOF {
+			// the stream got aborted, the status is returned by RecvMsg
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyBidiStream(newIn, newOut func() interface{}, srv grpc.ServerStream, client grpc.ClientStream, cancel context.CancelFunc) error {
+	errCh := make(chan error, 1)
+
+	go func() {
+		err := copyServerClient(newIn, srv, client)
+		if err != nil {
+			// abort the proxied stream
+			cancel()
+		} else {
+			// half-close, the responses keep flowing
+			err = client.CloseSend()
+		}
+
+		errCh <- err
+	}()
+
+	if err := copyClientServer(newOut, client, srv); err != nil {
+		select {
+		case inErr := <-errCh:
+			if inErr != nil {
+				return inErr
+			}
+		default:
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (p *ModesProxy) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		if !proxiedService(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		// the roles are checked whether the request is proxied or not, the
+		// route metadata being set by the callers
+		if err := runtime.CheckRoles(ss.Context(), info.FullMethod, methodRoles(info.FullMethod)); err != nil {
+			return err
+		}
+		forward, err := runtime.Forward(md, p.Name, p.MaxHops)
+		if err != nil {
+			return err
+		}
+		if !forward {
+			return handler(srv, ss)
+		}
+		switch info.FullMethod {
+		case "/routing.Routing/Local", "/routing.Routing/Events":
+			return handler(srv, ss)
+		}
+		if !routedMethod(info.FullMethod) {
+			if p.StreamFallback != nil {
+				return p.StreamFallback(srv, ss, info, handler)
+			}
+			return runtime.ErrUnknownMethod
+		}
+		creds, err := p.Credentials.Get(p.Provider)
+		if err != nil {
+			return err
+		}
+		return p.StreamProxy(ss, info.FullMethod, creds, srv)
+	}
+}
+
+func (p *ModesProxy) StreamProxy(ss grpc.ServerStream, method string, creds credentials.TransportCredentials, srv interface{}, opts ...grpc.CallOption) error {
+	return runtime.ErrUnknownMethod
+}
+
+// fanOutTargets returns the targets of a call made through the FanOut
+// methods, along with the credentials to dial them with and the metadata
+// to send them.
+func (p *ModesProxy) fanOutTargets(ctx context.Context, method string, targets []string) ([]string, credentials.TransportCredentials, metadata.MD, error) {
+	var err error
+	if p.Resolver != nil {
+		if targets, err = p.Resolver.Resolve(ctx, targets); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if err = p.TargetPolicy.Check(ctx, method, targets); err != nil {
+		return nil, nil, nil, err
+	}
+	creds, err := p.Credentials.Get(p.Provider)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	proxyMd := runtime.ForwardMetadata(md, p.ForwardedMetadata)
+	runtime.SetVia(proxyMd, md, p.Name)
+	return targets, creds, proxyMd, nil
+}
+
+// FanOutFanout calls Fanout on the targets the way the proxied
+// requests are, it returns the responses and the errors keyed by target.
+// The errors which aren't tied to a target, e.g. without any target, are
+// keyed by an empty target. The roles of the method and the Authorizer
+// aren't checked, the calls being made by the process itself.
+func (p *ModesProxy) FanOutFanout(ctx context.Context, targets []string, in *emptypb.Empty, opts ...grpc.CallOption) (map[string]*routing.FanoutResponse, map[string]error) {
+	resolved, creds, proxyMd, err := p.fanOutTargets(ctx, "/routing.Routing/Fanout", targets)
+	if err != nil {
+		return nil, runtime.ErrorForTargets(targets, err)
+	}
+	execution := runtime.Execution{Balancer: p.Balancer}
+	clients, release, dialErr := p.createRoutingClient(ctx, resolved, creds, proxyMd, opts)
+	defer release()
+	clientTargets := make([]string, len(clients))
+	for i, client := range clients {
+		clientTargets[i] = client.Target
+	}
+	msgs, errs := execution.RunTargets(metadata.NewOutgoingContext(ctx, proxyMd), clientTargets, func(ctx context.Context, i int) (proto.Message, error) {
+		return proxyFanout(ctx, clients[i], in)
+	})
+	// the targets which couldn't be dialed failed as well
+	for _, targetErr := range runtime.TargetErrors(dialErr) {
+		errs[targetErr.Target] = targetErr.Err
+	}
+	resps := make(map[string]*routing.FanoutResponse, len(msgs))
+	for target, msg := range msgs {
+		resps[target] = msg.(*routing.FanoutResponse)
+	}
+	return resps, errs
+}
+
+// FanOutStats calls Stats on the targets the way the proxied
+// requests are, it returns the responses and the errors keyed by target.
+// The errors which aren't tied to a target, e.g. without any target, are
+// keyed by an empty target. The roles of the method and the Authorizer
+// aren't checked, the calls being made by the process itself.
+func (p *ModesProxy) FanOutStats(ctx context.Context, targets []string, in *emptypb.Empty, opts ...grpc.CallOption) (map[string]*routing.StatsResponse, map[string]error) {
+	resolved, creds, proxyMd, err := p.fanOutTargets(ctx, "/routing.Routing/Stats", targets)
+	if err != nil {
+		return nil, runtime.ErrorForTargets(targets, err)
+	}
+	execution := runtime.Execution{MaxConcurrency: 2, TargetTimeout: 1500 * time.Millisecond, Balancer: p.Balancer}
+	opts = append(opts[:len(opts):len(opts)], grpc.MaxCallRecvMsgSize(16777216), grpc.WaitForReady(true))
+	clients, release, dialErr := p.createRoutingClient(ctx, resolved, creds, proxyMd, opts)
+	defer release()
+	clientTargets := make([]string, len(clients))
+	for i, client := range clients {
+		clientTargets[i] = client.Target
+	}
+	msgs, errs := execution.RunTargets(metadata.NewOutgoingContext(ctx, proxyMd), clientTargets, func(ctx context.Context, i int) (proto.Message, error) {
+		return proxyStats(ctx, clients[i], in)
+	})
+	// the targets which couldn't be dialed failed as well
+	for _, targetErr := range runtime.TargetErrors(dialErr) {
+		errs[targetErr.Target] = targetErr.Err
+	}
+	resps := make(map[string]*routing.StatsResponse, len(msgs))
+	for target, msg := range msgs {
+		resps[target] = msg.(*routing.StatsResponse)
+	}
+	return resps, errs
+}
+
+// FanOutPartial calls Partial on the targets the way the proxied
+// requests are, it returns the responses and the errors keyed by target.
+// The errors which aren't tied to a target, e.g. without any target, are
+// keyed by an empty target. The roles of the method and the Authorizer
+// aren't checked, the calls being made by the process itself.
+func (p *ModesProxy) FanOutPartial(ctx context.Context, targets []string, in *emptypb.Empty, opts ...grpc.CallOption) (map[string]*routing.FanoutResponse, map[string]error) {
+	resolved, creds, proxyMd, err := p.fanOutTargets(ctx, "/routing.Routing/Partial", targets)
+	if err != nil {
+		return nil, runtime.ErrorForTargets(targets, err)
+	}
+	execution := runtime.Execution{BatchSize: 1, StopOnError: true, Balancer: p.Balancer}
+	clients, release, dialErr := p.createRoutingClient(ctx, resolved, creds, proxyMd, opts)
+	defer release()
+	clientTargets := make([]string, len(clients))
+	for i, client := range clients {
+		clientTargets[i] = client.Target
+	}
+	msgs, errs := execution.RunTargets(metadata.NewOutgoingContext(ctx, proxyMd), clientTargets, func(ctx context.Context, i int) (proto.Message, error) {
+		return proxyPartial(ctx, clients[i], in)
+	})
+	// the targets which couldn't be dialed failed as well
+	for _, targetErr := range runtime.TargetErrors(dialErr) {
+		errs[targetErr.Target] = targetErr.Err
+	}
+	resps := make(map[string]*routing.FanoutResponse, len(msgs))
+	for target, msg := range msgs {
+		resps[target] = msg.(*routing.FanoutResponse)
+	}
+	return resps, errs
+}
+
+// FanOutAny calls Any on the targets the way the proxied
+// requests are, it returns the responses and the errors keyed by target.
+// The errors which aren't tied to a target, e.g. without any target, are
+// keyed by an empty target. The roles of the method and the Authorizer
+// aren't checked, the calls being made by the process itself.
+func (p *ModesProxy) FanOutAny(ctx context.Context, targets []string, in *emptypb.Empty, opts ...grpc.CallOption) (map[string]*routing.FanoutResponse, map[string]error) {
+	resolved, creds, proxyMd, err := p.fanOutTargets(ctx, "/routing.Routing/Any", targets)
+	if err != nil {
+		return nil, runtime.ErrorForTargets(targets, err)
+	}
+	execution := runtime.Execution{Dispatch: runtime.DispatchAny, Balancer: p.Balancer}
+	clients, release, dialErr := p.createRoutingClient(ctx, resolved, creds, proxyMd, opts)
+	defer release()
+	clientTargets := make([]string, len(clients))
+	for i, client := range clients {
+		clientTargets[i] = client.Target
+	}
+	execution.Unreachable = len(runtime.TargetErrors(dialErr))
+	msgs, errs := execution.RunTargets(metadata.NewOutgoingContext(ctx, proxyMd), clientTargets, func(ctx context.Context, i int) (proto.Message, error) {
+		return proxyAny(ctx, clients[i], in)
+	})
+	// the targets which couldn't be dialed failed as well
+	for _, targetErr := range runtime.TargetErrors(dialErr) {
+		errs[targetErr.Target] = targetErr.Err
+	}
+	resps := make(map[string]*routing.FanoutResponse, len(msgs))
+	for target, msg := range msgs {
+		resps[target] = msg.(*routing.FanoutResponse)
+	}
+	return resps, errs
+}
+
+// FanOutQuorum calls Quorum on the targets the way the proxied
+// requests are, it returns the responses and the errors keyed by target.
+// The errors which aren't tied to a target, e.g. without any target, are
+// keyed by an empty target. The roles of the method and the Authorizer
+// aren't checked, the calls being made by the process itself.
+func (p *ModesProxy) FanOutQuorum(ctx context.Context, targets []string, in *emptypb.Empty, opts ...grpc.CallOption) (map[string]*routing.FanoutResponse, map[string]error) {
+	resolved, creds, proxyMd, err := p.fanOutTargets(ctx, "/routing.Routing/Quorum", targets)
+	if err != nil {
+		return nil, runtime.ErrorForTargets(targets, err)
+	}
+	execution := runtime.Execution{Dispatch: runtime.DispatchQuorum, Balancer: p.Balancer}
+	clients, release, dialErr := p.createRoutingClient(ctx, resolved, creds, proxyMd, opts)
+	defer release()
+	clientTargets := make([]string, len(clients))
+	for i, client := range clients {
+		clientTargets[i] = client.Target
+	}
+	execution.Unreachable = len(runtime.TargetErrors(dialErr))
+	msgs, errs := execution.RunTargets(metadata.NewOutgoingContext(ctx, proxyMd), clientTargets, func(ctx context.Context, i int) (proto.Message, error) {
+		return proxyQuorum(ctx, clients[i], in)
+	})
+	// the targets which couldn't be dialed failed as well
+	for _, targetErr := range runtime.TargetErrors(dialErr) {
+		errs[targetErr.Target] = targetErr.Err
+	}
+	resps := make(map[string]*routing.FanoutResponse, len(msgs))
+	for target, msg := range msgs {
+		resps[target] = msg.(*routing.FanoutResponse)
+	}
+	return resps, errs
+}
+
+// FanOutOneOf calls OneOf on the targets the way the proxied
+// requests are, it returns the responses and the errors keyed by target.
+// The errors which aren't tied to a target, e.g. without any target, are
+// keyed by an empty target. The roles of the method and the Authorizer
+// aren't checked, the calls being made by the process itself.
+func (p *ModesProxy) FanOutOneOf(ctx context.Context, targets []string, in *emptypb.Empty, opts ...grpc.CallOption) (map[string]*routing.FanoutResponse, map[string]error) {
+	resolved, creds, proxyMd, err := p.fanOutTargets(ctx, "/routing.Routing/OneOf", targets)
+	if err != nil {
+		return nil, runtime.ErrorForTargets(targets, err)
+	}
+	execution := runtime.Execution{Dispatch: runtime.DispatchOneOf, Balancing: runtime.LeastLoaded, Balancer: p.Balancer}
+	clients, release, dialErr := p.createRoutingClient(ctx, resolved, creds, proxyMd, opts)
+	defer release()
+	clientTargets := make([]string, len(clients))
+	for i, client := range clients {
+		clientTargets[i] = client.Target
+	}
+	execution.Unreachable = len(runtime.TargetErrors(dialErr))
+	msgs, errs := execution.RunTargets(metadata.NewOutgoingContext(ctx, proxyMd), clientTargets, func(ctx context.Context, i int) (proto.Message, error) {
+		return proxyOneOf(ctx, clients[i], in)
+	})
+	// the targets which couldn't be dialed failed as well
+	for _, targetErr := range runtime.TargetErrors(dialErr) {
+		errs[targetErr.Target] = targetErr.Err
+	}
+	resps := make(map[string]*routing.FanoutResponse, len(msgs))
+	for target, msg := range msgs {
+		resps[target] = msg.(*routing.FanoutResponse)
+	}
+	return resps, errs
+}
+
+// FanOutMajority calls Majority on the targets the way the proxied
+// requests are, it returns the responses and the errors keyed by target.
+// The errors which aren't tied to a target, e.g. without any target, are
+// keyed by an empty target. The roles of the method and the Authorizer
+// aren't checked, the calls being made by the process itself.
+func (p *ModesProxy) FanOutMajority(ctx context.Context, targets []string, in *emptypb.Empty, opts ...grpc.CallOption) (map[string]*routing.FanoutResponse, map[string]error) {
+	resolved, creds, proxyMd, err := p.fanOutTargets(ctx, "/routing.Routing/Majority", targets)
+	if err != nil {
+		return nil, runtime.ErrorForTargets(targets, err)
+	}
+	execution := runtime.Execution{Dispatch: runtime.DispatchQuorum, Balancer: p.Balancer}
+	clients, release, dialErr := p.createRoutingClient(ctx, resolved, creds, proxyMd, opts)
+	defer release()
+	clientTargets := make([]string, len(clients))
+	for i, client := range clients {
+		clientTargets[i] = client.Target
+	}
+	execution.Unreachable = len(runtime.TargetErrors(dialErr))
+	msgs, errs := execution.RunTargets(metadata.NewOutgoingContext(ctx, proxyMd), clientTargets, func(ctx context.Context, i int) (proto.Message, error) {
+		return proxyMajority(ctx, clients[i], in)
+	})
+	// the targets which couldn't be dialed failed as well
+	for _, targetErr := range runtime.TargetErrors(dialErr) {
+		errs[targetErr.Target] = targetErr.Err
+	}
+	resps := make(map[string]*routing.FanoutResponse, len(msgs))
+	for target, msg := range msgs {
+		resps[target] = msg.(*routing.FanoutResponse)
+	}
+	return resps, errs
+}
+
+type runnerRoutingFn func(context.Context, *proxyRoutingClient, interface{}) (proto.Message, error)
+
+func proxyRoutingRunner(ctx context.Context, clients []*proxyRoutingClient, in interface{}, runner runnerRoutingFn, execution runtime.Execution) ([]proto.Message, error) {
+	targets := make([]string, len(clients))
+	for i, client := range clients {
+		targets[i] = client.Target
+	}
+
+	return execution.Run(ctx, targets, func(ctx context.Context, i int) (proto.Message, error) {
+		return runner(ctx, clients[i], in)
+	})
+}
+
+type proxyRoutingClient struct {
+	Conn     routing.RoutingClient
+	Context  context.Context
+	Target   string
+	DialOpts []grpc.DialOption
+	CallOpts []grpc.CallOption
+}
+
+func proxyFanout(ctx context.Context, client *proxyRoutingClient, in interface{}) (proto.Message, error) {
+	resp, err := client.Conn.Fanout(ctx, in.(*emptypb.Empty), client.CallOpts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range resp.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = &common.NodeMetadata{}
+		}
+		msg.Metadata.Hostname = client.Target
+	}
+	return resp, nil
+}
+
+func proxyStats(ctx context.Context, client *proxyRoutingClient, in interface{}) (proto.Message, error) {
+	resp, err := client.Conn.Stats(ctx, in.(*emptypb.Empty), client.CallOpts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range resp.Stats {
+		if msg.Node == nil {
+			msg.Node = &common.NodeMetadata{}
+		}
+		msg.Node.Hostname = client.Target
+	}
+	return resp, nil
+}
+
+func proxyPartial(ctx context.Context, client *proxyRoutingClient, in interface{}) (proto.Message, error) {
+	resp, err := client.Conn.Partial(ctx, in.(*emptypb.Empty), client.CallOpts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range resp.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = &common.NodeMetadata{}
+		}
+		msg.Metadata.Hostname = client.Target
+	}
+	return resp, nil
+}
+
+func proxyAny(ctx context.Context, client *proxyRoutingClient, in interface{}) (proto.Message, error) {
+	resp, err := client.Conn.Any(ctx, in.(*emptypb.Empty), client.CallOpts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range resp.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = &common.NodeMetadata{}
+		}
+		msg.Metadata.Hostname = client.Target
+	}
+	return resp, nil
+}
+
+func proxyQuorum(ctx context.Context, client *proxyRoutingClient, in interface{}) (proto.Message, error) {
+	resp, err := client.Conn.Quorum(ctx, in.(*emptypb.Empty), client.CallOpts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range resp.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = &common.NodeMetadata{}
+		}
+		msg.Metadata.Hostname = client.Target
+	}
+	return resp, nil
+}
+
+func proxyOneOf(ctx context.Context, client *proxyRoutingClient, in interface{}) (proto.Message, error) {
+	resp, err := client.Conn.OneOf(ctx, in.(*emptypb.Empty), client.CallOpts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range resp.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = &common.NodeMetadata{}
+		}
+		msg.Metadata.Hostname = client.Target
+	}
+	return resp, nil
+}
+
+func proxyMajority(ctx context.Context, client *proxyRoutingClient, in interface{}) (proto.Message, error) {
+	resp, err := client.Conn.Majority(ctx, in.(*emptypb.Empty), client.CallOpts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range resp.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = &common.NodeMetadata{}
+		}
+		msg.Metadata.Hostname = client.Target
+	}
+	return resp, nil
+}
+
+func (p *ModesProxy) createRoutingClient(ctx context.Context, targets []string, creds credentials.TransportCredentials, proxyMd metadata.MD, opts []grpc.CallOption) ([]*proxyRoutingClient, func(), error) {
+	var errs []error
+	clients := make([]*proxyRoutingClient, 0, len(targets))
+	releases := make([]func(), 0, len(targets))
+	for _, target := range targets {
+		c := &proxyRoutingClient{
+			Context:  metadata.NewOutgoingContext(ctx, proxyMd),
+			Target:   target,
+			DialOpts: p.DialOptions,
+			CallOpts: opts,
+		}
+		dialTarget, err := runtime.DialTarget(target, p.DefaultPort)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		targetCreds, err := runtime.TargetCredentials(target, creds, p.CredentialsFor, p.VerifyTargetName)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		conn, release, err := p.Pool.Get(p.Dialer, dialTarget, targetCreds, c.DialOpts...)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		releases = append(releases, release)
+		c.Conn = routing.NewRoutingClient(conn)
+		clients = append(clients, c)
+	}
+	return clients, func() {
+		for _, release := range releases {
+			release()
+		}
+	}, runtime.JoinErrors(errs...)
+}
+
+type Registrator struct {
+	routing.RoutingClient
+	routing.UnimplementedRoutingServer
+}
+
+func (r *Registrator) Register(s *grpc.Server) {
+	routing.RegisterRoutingServer(s, r)
+
+}
+
+func (r *Registrator) Fanout(ctx context.Context, in *emptypb.Empty) (*routing.FanoutResponse, error) {
+	return r.RoutingClient.Fanout(ctx, in)
+}
+func (r *Registrator) Single(ctx context.Context, in *emptypb.Empty) (*routing.Reply, error) {
+	return r.RoutingClient.Single(ctx, in)
+}
+func (r *Registrator) Local(ctx context.Context, in *emptypb.Empty) (*routing.Reply, error) {
+	return r.RoutingClient.Local(ctx, in)
+}
+func (r *Registrator) Skipped(ctx context.Context, in *emptypb.Empty) (*routing.Reply, error) {
+	return r.RoutingClient.Skipped(ctx, in)
+}
+func (r *Registrator) Stats(ctx context.Context, in *emptypb.Empty) (*routing.StatsResponse, error) {
+	return r.RoutingClient.Stats(ctx, in)
+}
+func (r *Registrator) Partial(ctx context.Context, in *emptypb.Empty) (*routing.FanoutResponse, error) {
+	return r.RoutingClient.Partial(ctx, in)
+}
+func (r *Registrator) Any(ctx context.Context, in *emptypb.Empty) (*routing.FanoutResponse, error) {
+	return r.RoutingClient.Any(ctx, in)
+}
+func (r *Registrator) Quorum(ctx context.Context, in *emptypb.Empty) (*routing.FanoutResponse, error) {
+	return r.RoutingClient.Quorum(ctx, in)
+}
+func (r *Registrator) OneOf(ctx context.Context, in *emptypb.Empty) (*routing.FanoutResponse, error) {
+	return r.RoutingClient.OneOf(ctx, in)
+}
+func (r *Registrator) Majority(ctx context.Context, in *emptypb.Empty) (*routing.FanoutResponse, error) {
+	return r.RoutingClient.Majority(ctx, in)
+}
+func (r *Registrator) Events(in *emptypb.Empty, srv routing.Routing_EventsServer) error {
+	client, err := r.RoutingClient.Events(srv.Context(), in)
+	if err != nil {
+		return err
+	}
+	return copyClientServer(func() interface{} { return new(routing.Reply) }, client, srv)
+}
+
+type LocalRoutingClient struct {
+	routing.RoutingClient
+}
+
+func NewLocalRoutingClient() (routing.RoutingClient, error) {
+	conn, err := grpc.Dial("unix:"+runtime.SocketPath("routing.Routing"),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalRoutingClient{
+		RoutingClient: routing.NewRoutingClient(conn),
+	}, nil
+}
+
+func (c *LocalRoutingClient) Fanout(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*routing.FanoutResponse, error) {
+	return c.RoutingClient.Fanout(ctx, in, opts...)
+}
+func (c *LocalRoutingClient) Single(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*routing.Reply, error) {
+	return c.RoutingClient.Single(ctx, in, opts...)
+}
+func (c *LocalRoutingClient) Local(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*routing.Reply, error) {
+	return c.RoutingClient.Local(ctx, in, opts...)
+}
+func (c *LocalRoutingClient) Skipped(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*routing.Reply, error) {
+	return c.RoutingClient.Skipped(ctx, in, opts...)
+}
+func (c *LocalRoutingClient) Stats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*routing.StatsResponse, error) {
+	return c.RoutingClient.Stats(ctx, in, opts...)
+}
+func (c *LocalRoutingClient) Partial(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*routing.FanoutResponse, error) {
+	return c.RoutingClient.Partial(ctx, in, opts...)
+}
+func (c *LocalRoutingClient) Any(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*routing.FanoutResponse, error) {
+	return c.RoutingClient.Any(ctx, in, opts...)
+}
+func (c *LocalRoutingClient) Quorum(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*routing.FanoutResponse, error) {
+	return c.RoutingClient.Quorum(ctx, in, opts...)
+}
+func (c *LocalRoutingClient) OneOf(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*routing.FanoutResponse, error) {
+	return c.RoutingClient.OneOf(ctx, in, opts...)
+}
+func (c *LocalRoutingClient) Majority(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*routing.FanoutResponse, error) {
+	return c.RoutingClient.Majority(ctx, in, opts...)
+}
+func (c *LocalRoutingClient) Events(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (routing.Routing_EventsClient, error) {
+	return c.RoutingClient.Events(ctx, in, opts...)
+}
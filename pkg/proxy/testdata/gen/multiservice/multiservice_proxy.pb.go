@@ -0,0 +1,909 @@
+// Code generated by protoc-gen-proxy. DO NOT EDIT.
+// source: multiservice.proto
+
+package multiservice
+
+import (
+	context "context"
+	cluster "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/cluster"
+	common "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/common"
+	node "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/node"
+	runtime "github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	credentials "google.golang.org/grpc/credentials"
+	metadata "google.golang.org/grpc/metadata"
+	status "google.golang.org/grpc/status"
+	proto "google.golang.org/protobuf/proto"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	io "io"
+)
+
+type MultiserviceProxy struct {
+	Provider runtime.CertificateProvider
+	// Credentials caches the credentials built from the Provider until it
+	// signals a rotation, a nil Credentials builds them on each request.
+	Credentials *runtime.CredentialsCache
+	// CredentialsFor returns the credentials to dial a target with, e.g. to
+	// trust the CA of its cluster. The targets it returns nil credentials
+	// for, or all of them when unset, are dialed with the Credentials.
+	CredentialsFor runtime.CredentialsFunc
+	// VerifyTargetName requires the certificate of each target to be valid
+	// for the host it was requested as.
+	VerifyTargetName bool
+	// Name identifies the proxy in the route of the requests, it defaults to
+	// the authority of each request.
+	Name string
+	// MaxHops is the number of proxies a request may go through, the
+	// requests which went through as many get handled locally. Zero only
+	// lets a single proxy forward them.
+	MaxHops int
+	// DefaultPort is dialed when a target doesn't specify a port.
+	DefaultPort int
+	// Pool holds the connections to the targets, a nil Pool dials them on
+	// each request.
+	Pool *runtime.Pool
+	// DialOptions are applied when dialing the targets, e.g. keepalives,
+	// message sizes or a custom dialer. The pooled connections keep the
+	// options they were dialed with.
+	DialOptions []grpc.DialOption
+	// Dialer creates the connections to the targets, e.g. to in-memory
+	// servers in tests with a runtimetest.BufconnDialer. The targets are dialed
+	// over the network when unset.
+	Dialer runtime.Dialer
+	// Balancer spreads the methods dispatched to one of their targets, it
+	// tracks the calls in flight to each target.
+	Balancer *runtime.Balancer
+	// ForwardedMetadata lists the keys of the incoming metadata passed on to
+	// the targets.
+	ForwardedMetadata []string
+	// Resolver expands the logical targets of the requests, such as "all",
+	// into the addresses of the nodes. The targets are used as is when unset.
+	Resolver runtime.Resolver
+	// TargetPolicy restricts the targets the requests may ask for, any
+	// target is allowed when unset.
+	TargetPolicy *runtime.TargetPolicy
+	// Authorizer decides whether the requests get proxied and may narrow
+	// their targets, once the caller is checked against the roles of the
+	// method.
+	Authorizer runtime.Authorizer
+	// UnaryFallback and StreamFallback handle the methods of the proxied
+	// services the proxy doesn't route, those fail with Unimplemented when
+	// unset. runtime.LocalUnaryFallback and runtime.LocalStreamFallback
+	// hand them over to the local server.
+	UnaryFallback  grpc.UnaryServerInterceptor
+	StreamFallback grpc.StreamServerInterceptor
+}
+
+func NewMultiserviceProxy(provider runtime.CertificateProvider) *MultiserviceProxy {
+	return &MultiserviceProxy{
+		Provider:    provider,
+		Credentials: runtime.NewCredentialsCache(),
+		Pool:        runtime.NewPool(runtime.DefaultIdleTimeout),
+		Balancer:    runtime.NewBalancer(),
+	}
+}
+
+// Close closes the connections to the targets.
+func (p *MultiserviceProxy) Close() error {
+	return p.Pool.Close()
+}
+
+func proxiedService(fullMethod string) bool {
+	switch runtime.ServiceName(fullMethod) {
+	case "node.Node", "cluster.Cluster", "cluster.Etcd":
+		return true
+	}
+	return false
+}
+
+func routedMethod(fullMethod string) bool {
+	switch fullMethod {
+	case "/node.Node/Hostname", "/node.Node/Uptime", "/cluster.Cluster/Members", "/cluster.Etcd/Leave", "/cluster.Etcd/Watch":
+		return true
+	}
+	return false
+}
+
+func methodRoles(fullMethod string) []string {
+	return nil
+}
+
+func (p *MultiserviceProxy) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if !proxiedService(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		// the roles are checked whether the request is proxied or not, the
+		// route metadata being set by the callers
+		if err := runtime.CheckRoles(ctx, info.FullMethod, methodRoles(info.FullMethod)); err != nil {
+			return nil, err
+		}
+		forward, err := runtime.Forward(md, p.Name, p.MaxHops)
+		if err != nil {
+			return nil, err
+		}
+		if !forward {
+			return handler(ctx, req)
+		}
+		if !routedMethod(info.FullMethod) {
+			if p.UnaryFallback != nil {
+				return p.UnaryFallback(ctx, req, info, handler)
+			}
+			return nil, runtime.ErrUnknownMethod
+		}
+		creds, err := p.Credentials.Get(p.Provider)
+		if err != nil {
+			return nil, err
+		}
+		return p.UnaryProxy(ctx, info.FullMethod, creds, req)
+	}
+}
+
+func (p *MultiserviceProxy) UnaryProxy(ctx context.Context, method string, creds credentials.TransportCredentials, in interface{}, opts ...grpc.CallOption) (proto.Message, error) {
+	var (
+		err      error
+		msgs     []proto.Message
+		response proto.Message
+		targets  []string
+	)
+	md, _ := metadata.FromIncomingContext(ctx)
+	// the requests without targets are meant for the proxy node itself, they
+	// are rejected rather than proxied to its own authority
+	targets = md["targets"]
+	if p.Resolver != nil {
+		if targets, err = p.Resolver.Resolve(ctx, targets); err != nil {
+			return nil, err
+		}
+	}
+	// the targets are checked before any of them gets dialed
+	if err = p.TargetPolicy.Check(ctx, method, targets); err != nil {
+		return nil, err
+	}
+	if targets, err = runtime.Authorize(ctx, p.Authorizer, method, methodRoles(method), targets, in); err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no targets to proxy to")
+	}
+	proxyMd := runtime.ForwardMetadata(md, p.ForwardedMetadata)
+	runtime.SetVia(proxyMd, md, p.Name)
+
+	switch method {
+	case "/node.Node/Hostname":
+		execution := runtime.Execution{Balancer: p.Balancer}
+		if err = execution.ApplyMetadata(md); err != nil {
+			break
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createNodeClient(ctx, targets, creds, proxyMd, opts)
+		defer release()
+		resp := &node.HostnameResponse{}
+		msgs, err = proxyNodeRunner(metadata.NewOutgoingContext(ctx, proxyMd), clients, in, proxyHostname, execution)
+		for _, msg := range msgs {
+			resp.Response = append(resp.Response, msg.(*node.HostnameResponse).Response...)
+		}
+		response = resp
+		// the targets which couldn't be dialed failed as well
+		err = runtime.JoinErrors(dialErr, err)
+	case "/node.Node/Uptime":
+		execution := runtime.Execution{Balancer: p.Balancer}
+		if err = execution.ApplyMetadata(md); err != nil {
+			break
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createNodeClient(ctx, targets, creds, proxyMd, opts)
+		defer release()
+		resp := &node.UptimeResponse{}
+		msgs, err = proxyNodeRunner(metadata.NewOutgoingContext(ctx, proxyMd), clients, in, proxyUptime, execution)
+		for _, msg := range msgs {
+			resp.Response = append(resp.Response, msg.(*node.UptimeResponse).Response...)
+		}
+		response = resp
+		// the targets which couldn't be dialed failed as well
+		err = runtime.JoinErrors(dialErr, err)
+	case "/cluster.Cluster/Members":
+		execution := runtime.Execution{Balancer: p.Balancer}
+		if err = execution.ApplyMetadata(md); err != nil {
+			break
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createClusterClient(ctx, targets, creds, proxyMd, opts)
+		defer release()
+		resp := &cluster.MembersResponse{}
+		msgs, err = proxyClusterRunner(metadata.NewOutgoingContext(ctx, proxyMd), clients, in, proxyMembers, execution)
+		for _, msg := range msgs {
+			resp.Response = append(resp.Response, msg.(*cluster.MembersResponse).Response...)
+		}
+		response = resp
+		// the targets which couldn't be dialed failed as well
+		err = runtime.JoinErrors(dialErr, err)
+	case "/cluster.Etcd/Leave":
+		execution := runtime.Execution{Balancer: p.Balancer}
+		if err = execution.ApplyMetadata(md); err != nil {
+			break
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createEtcdClient(ctx, targets, creds, proxyMd, opts)
+		defer release()
+		resp := &cluster.LeaveResponse{}
+		msgs, err = proxyEtcdRunner(metadata.NewOutgoingContext(ctx, proxyMd), clients, in, proxyLeave, execution)
+		for _, msg := range msgs {
+			resp.Response = append(resp.Response, msg.(*cluster.LeaveResponse).Response...)
+		}
+		response = resp
+		// the targets which couldn't be dialed failed as well
+		err = runtime.JoinErrors(dialErr, err)
+
+	default:
+		return nil, runtime.ErrUnknownMethod
+	}
+
+	return response, err
+}
+func copyClientServer(newMsg func() interface{}, client grpc.ClientStream, srv grpc.ServerStream) error {
+	for {
+		msg := newMsg()
+		err := client.RecvMsg(msg)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = srv.SendMsg(msg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyServerClient(newMsg func() interface{}, srv grpc.ServerStream, client grpc.ClientStream) error {
+	for {
+		msg := newMsg()
+		err := srv.RecvMsg(msg)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = client.SendMsg(msg)
+		if err == io.EOF {
+			// the stream got aborted, the status is returned by RecvMsg
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyBidiStream(newIn, newOut func() interface{}, srv grpc.ServerStream, client grpc.ClientStream, cancel context.CancelFunc) error {
+	errCh := make(chan error, 1)
+
+	go func() {
+		err := copyServerClient(newIn, srv, client)
+		if err != nil {
+			// abort the proxied stream
+			cancel()
+		} else {
+			// half-close, the responses keep flowing
+			err = client.CloseSend()
+		}
+
+		errCh <- err
+	}()
+
+	if err := copyClientServer(newOut, client, srv); err != nil {
+		select {
+		case inErr := <-errCh:
+			if inErr != nil {
+				return inErr
+			}
+		default:
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (p *MultiserviceProxy) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		if !proxiedService(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		// the roles are checked whether the request is proxied or not, the
+		// route metadata being set by the callers
+		if err := runtime.CheckRoles(ss.Context(), info.FullMethod, methodRoles(info.FullMethod)); err != nil {
+			return err
+		}
+		forward, err := runtime.Forward(md, p.Name, p.MaxHops)
+		if err != nil {
+			return err
+		}
+		if !forward {
+			return handler(srv, ss)
+		}
+		if !routedMethod(info.FullMethod) {
+			if p.StreamFallback != nil {
+				return p.StreamFallback(srv, ss, info, handler)
+			}
+			return runtime.ErrUnknownMethod
+		}
+		creds, err := p.Credentials.Get(p.Provider)
+		if err != nil {
+			return err
+		}
+		return p.StreamProxy(ss, info.FullMethod, creds, srv)
+	}
+}
+
+func (p *MultiserviceProxy) StreamProxy(ss grpc.ServerStream, method string, creds credentials.TransportCredentials, srv interface{}, opts ...grpc.CallOption) error {
+	var (
+		err     error
+		targets []string
+		in      interface{}
+	)
+
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	// the requests without targets are meant for the proxy node itself, they
+	// are rejected rather than proxied to its own authority
+	targets = md["targets"]
+	if p.Resolver != nil {
+		if targets, err = p.Resolver.Resolve(ss.Context(), targets); err != nil {
+			return err
+		}
+	}
+	// the targets are checked before any of them gets dialed
+	if err = p.TargetPolicy.Check(ss.Context(), method, targets); err != nil {
+		return err
+	}
+	// the request of the server streams is received first for the
+	// authorizer to see it
+	switch method {
+	case "/cluster.Etcd/Watch":
+		m := new(cluster.WatchRequest)
+		if err = ss.RecvMsg(m); err != nil {
+			return err
+		}
+		in = m
+
+	}
+	if targets, err = runtime.Authorize(ss.Context(), p.Authorizer, method, methodRoles(method), targets, in); err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return status.Error(codes.InvalidArgument, "no targets to proxy to")
+	}
+
+	proxyMd := runtime.ForwardMetadata(md, p.ForwardedMetadata)
+	runtime.SetVia(proxyMd, md, p.Name)
+
+	switch method {
+	case "/cluster.Etcd/Watch":
+		// Initialize target clients
+		clients, release, dialErr := p.createEtcdClient(ss.Context(), targets, creds, proxyMd, opts)
+		defer release()
+		if len(clients) == 0 {
+			err = dialErr
+			break
+		}
+		m := in.(*cluster.WatchRequest)
+		sources := make([]runtime.StreamSource, 0, len(clients))
+		for _, client := range clients {
+			client := client
+			sources = append(sources, runtime.StreamSource{
+				Target:  client.Target,
+				Context: client.Context,
+				Open: func(ctx context.Context) (grpc.ClientStream, error) {
+					return client.Conn.Watch(ctx, m, client.CallOpts...)
+				},
+			})
+		}
+		err = runtime.JoinErrors(dialErr, runtime.MergeStreams(ss, sources, func() proto.Message { return new(cluster.Event) }, func(msg proto.Message, target string) {
+			m := msg.(*cluster.Event)
+			if m.Metadata == nil {
+				m.Metadata = &common.NodeMetadata{}
+			}
+			m.Metadata.Hostname = target
+		}))
+
+	default:
+		return runtime.ErrUnknownMethod
+	}
+
+	return err
+}
+
+// fanOutTargets returns the targets of a call made through the FanOut
+// methods, along with the credentials to dial them with and the metadata
+// to send them.
+func (p *MultiserviceProxy) fanOutTargets(ctx context.Context, method string, targets []string) ([]string, credentials.TransportCredentials, metadata.MD, error) {
+	var err error
+	if p.Resolver != nil {
+		if targets, err = p.Resolver.Resolve(ctx, targets); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if err = p.TargetPolicy.Check(ctx, method, targets); err != nil {
+		return nil, nil, nil, err
+	}
+	creds, err := p.Credentials.Get(p.Provider)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	proxyMd := runtime.ForwardMetadata(md, p.ForwardedMetadata)
+	runtime.SetVia(proxyMd, md, p.Name)
+	return targets, creds, proxyMd, nil
+}
+
+// FanOutHostname calls Hostname on the targets the way the proxied
+// requests are, it returns the responses and the errors keyed by target.
+// The errors which aren't tied to a target, e.g. without any target, are
+// keyed by an empty target. The roles of the method and the Authorizer
+// aren't checked, the calls being made by the process itself.
+func (p *MultiserviceProxy) FanOutHostname(ctx context.Context, targets []string, in *emptypb.Empty, opts ...grpc.CallOption) (map[string]*node.HostnameResponse, map[string]error) {
+	resolved, creds, proxyMd, err := p.fanOutTargets(ctx, "/node.Node/Hostname", targets)
+	if err != nil {
+		return nil, runtime.ErrorForTargets(targets, err)
+	}
+	execution := runtime.Execution{Balancer: p.Balancer}
+	clients, release, dialErr := p.createNodeClient(ctx, resolved, creds, proxyMd, opts)
+	defer release()
+	clientTargets := make([]string, len(clients))
+	for i, client := range clients {
+		clientTargets[i] = client.Target
+	}
+	msgs, errs := execution.RunTargets(metadata.NewOutgoingContext(ctx, proxyMd), clientTargets, func(ctx context.Context, i int) (proto.Message, error) {
+		return proxyHostname(ctx, clients[i], in)
+	})
+	// the targets which couldn't be dialed failed as well
+	for _, targetErr := range runtime.TargetErrors(dialErr) {
+		errs[targetErr.Target] = targetErr.Err
+	}
+	resps := make(map[string]*node.HostnameResponse, len(msgs))
+	for target, msg := range msgs {
+		resps[target] = msg.(*node.HostnameResponse)
+	}
+	return resps, errs
+}
+
+// FanOutUptime calls Uptime on the targets the way the proxied
+// requests are, it returns the responses and the errors keyed by target.
+// The errors which aren't tied to a target, e.g. without any target, are
+// keyed by an empty target. The roles of the method and the Authorizer
+// aren't checked, the calls being made by the process itself.
+func (p *MultiserviceProxy) FanOutUptime(ctx context.Context, targets []string, in *node.UptimeRequest, opts ...grpc.CallOption) (map[string]*node.UptimeResponse, map[string]error) {
+	resolved, creds, proxyMd, err := p.fanOutTargets(ctx, "/node.Node/Uptime", targets)
+	if err != nil {
+		return nil, runtime.ErrorForTargets(targets, err)
+	}
+	execution := runtime.Execution{Balancer: p.Balancer}
+	clients, release, dialErr := p.createNodeClient(ctx, resolved, creds, proxyMd, opts)
+	defer release()
+	clientTargets := make([]string, len(clients))
+	for i, client := range clients {
+		clientTargets[i] = client.Target
+	}
+	msgs, errs := execution.RunTargets(metadata.NewOutgoingContext(ctx, proxyMd), clientTargets, func(ctx context.Context, i int) (proto.Message, error) {
+		return proxyUptime(ctx, clients[i], in)
+	})
+	// the targets which couldn't be dialed failed as well
+	for _, targetErr := range runtime.TargetErrors(dialErr) {
+		errs[targetErr.Target] = targetErr.Err
+	}
+	resps := make(map[string]*node.UptimeResponse, len(msgs))
+	for target, msg := range msgs {
+		resps[target] = msg.(*node.UptimeResponse)
+	}
+	return resps, errs
+}
+
+// FanOutMembers calls Members on the targets the way the proxied
+// requests are, it returns the responses and the errors keyed by target.
+// The errors which aren't tied to a target, e.g. without any target, are
+// keyed by an empty target. The roles of the method and the Authorizer
+// aren't checked, the calls being made by the process itself.
+func (p *MultiserviceProxy) FanOutMembers(ctx context.Context, targets []string, in *cluster.MembersRequest, opts ...grpc.CallOption) (map[string]*cluster.MembersResponse, map[string]error) {
+	resolved, creds, proxyMd, err := p.fanOutTargets(ctx, "/cluster.Cluster/Members", targets)
+	if err != nil {
+		return nil, runtime.ErrorForTargets(targets, err)
+	}
+	execution := runtime.Execution{Balancer: p.Balancer}
+	clients, release, dialErr := p.createClusterClient(ctx, resolved, creds, proxyMd, opts)
+	defer release()
+	clientTargets := make([]string, len(clients))
+	for i, client := range clients {
+		clientTargets[i] = client.Target
+	}
+	msgs, errs := execution.RunTargets(metadata.NewOutgoingContext(ctx, proxyMd), clientTargets, func(ctx context.Context, i int) (proto.Message, error) {
+		return proxyMembers(ctx, clients[i], in)
+	})
+	// the targets which couldn't be dialed failed as well
+	for _, targetErr := range runtime.TargetErrors(dialErr) {
+		errs[targetErr.Target] = targetErr.Err
+	}
+	resps := make(map[string]*cluster.MembersResponse, len(msgs))
+	for target, msg := range msgs {
+		resps[target] = msg.(*cluster.MembersResponse)
+	}
+	return resps, errs
+}
+
+// FanOutLeave calls Leave on the targets the way the proxied
+// requests are, it returns the responses and the errors keyed by target.
+// The errors which aren't tied to a target, e.g. without any target, are
+// keyed by an empty target. The roles of the method and the Authorizer
+// aren't checked, the calls being made by the process itself.
+func (p *MultiserviceProxy) FanOutLeave(ctx context.Context, targets []string, in *cluster.LeaveRequest, opts ...grpc.CallOption) (map[string]*cluster.LeaveResponse, map[string]error) {
+	resolved, creds, proxyMd, err := p.fanOutTargets(ctx, "/cluster.Etcd/Leave", targets)
+	if err != nil {
+		return nil, runtime.ErrorForTargets(targets, err)
+	}
+	execution := runtime.Execution{Balancer: p.Balancer}
+	clients, release, dialErr := p.createEtcdClient(ctx, resolved, creds, proxyMd, opts)
+	defer release()
+	clientTargets := make([]string, len(clients))
+	for i, client := range clients {
+		clientTargets[i] = client.Target
+	}
+	msgs, errs := execution.RunTargets(metadata.NewOutgoingContext(ctx, proxyMd), clientTargets, func(ctx context.Context, i int) (proto.Message, error) {
+		return proxyLeave(ctx, clients[i], in)
+	})
+	// the targets which couldn't be dialed failed as well
+	for _, targetErr := range runtime.TargetErrors(dialErr) {
+		errs[targetErr.Target] = targetErr.Err
+	}
+	resps := make(map[string]*cluster.LeaveResponse, len(msgs))
+	for target, msg := range msgs {
+		resps[target] = msg.(*cluster.LeaveResponse)
+	}
+	return resps, errs
+}
+
+type runnerNodeFn func(context.Context, *proxyNodeClient, interface{}) (proto.Message, error)
+
+func proxyNodeRunner(ctx context.Context, clients []*proxyNodeClient, in interface{}, runner runnerNodeFn, execution runtime.Execution) ([]proto.Message, error) {
+	targets := make([]string, len(clients))
+	for i, client := range clients {
+		targets[i] = client.Target
+	}
+
+	return execution.Run(ctx, targets, func(ctx context.Context, i int) (proto.Message, error) {
+		return runner(ctx, clients[i], in)
+	})
+}
+
+type proxyNodeClient struct {
+	Conn     node.NodeClient
+	Context  context.Context
+	Target   string
+	DialOpts []grpc.DialOption
+	CallOpts []grpc.CallOption
+}
+
+func proxyHostname(ctx context.Context, client *proxyNodeClient, in interface{}) (proto.Message, error) {
+	resp, err := client.Conn.Hostname(ctx, in.(*emptypb.Empty), client.CallOpts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range resp.Response {
+		if msg.Metadata == nil {
+			msg.Metadata = &common.NodeMetadata{}
+		}
+		msg.Metadata.Hostname = client.Target
+	}
+	return resp, nil
+}
+
+func proxyUptime(ctx context.Context, client *proxyNodeClient, in interface{}) (proto.Message, error) {
+	resp, err := client.Conn.Uptime(ctx, in.(*node.UptimeRequest), client.CallOpts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range resp.Response {
+		if msg.Metadata == nil {
+			msg.Metadata = &common.NodeMetadata{}
+		}
+		msg.Metadata.Hostname = client.Target
+	}
+	return resp, nil
+}
+
+type runnerClusterFn func(context.Context, *proxyClusterClient, interface{}) (proto.Message, error)
+
+func proxyClusterRunner(ctx context.Context, clients []*proxyClusterClient, in interface{}, runner runnerClusterFn, execution runtime.Execution) ([]proto.Message, error) {
+	targets := make([]string, len(clients))
+	for i, client := range clients {
+		targets[i] = client.Target
+	}
+
+	return execution.Run(ctx, targets, func(ctx context.Context, i int) (proto.Message, error) {
+		return runner(ctx, clients[i], in)
+	})
+}
+
+type proxyClusterClient struct {
+	Conn     cluster.ClusterClient
+	Context  context.Context
+	Target   string
+	DialOpts []grpc.DialOption
+	CallOpts []grpc.CallOption
+}
+
+func proxyMembers(ctx context.Context, client *proxyClusterClient, in interface{}) (proto.Message, error) {
+	resp, err := client.Conn.Members(ctx, in.(*cluster.MembersRequest), client.CallOpts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range resp.Response {
+		if msg.Metadata == nil {
+			msg.Metadata = &common.NodeMetadata{}
+		}
+		msg.Metadata.Hostname = client.Target
+	}
+	return resp, nil
+}
+
+type runnerEtcdFn func(context.Context, *proxyEtcdClient, interface{}) (proto.Message, error)
+
+func proxyEtcdRunner(ctx context.Context, clients []*proxyEtcdClient, in interface{}, runner runnerEtcdFn, execution runtime.Execution) ([]proto.Message, error) {
+	targets := make([]string, len(clients))
+	for i, client := range clients {
+		targets[i] = client.Target
+	}
+
+	return execution.Run(ctx, targets, func(ctx context.Context, i int) (proto.Message, error) {
+		return runner(ctx, clients[i], in)
+	})
+}
+
+type proxyEtcdClient struct {
+	Conn     cluster.EtcdClient
+	Context  context.Context
+	Target   string
+	DialOpts []grpc.DialOption
+	CallOpts []grpc.CallOption
+}
+
+func proxyLeave(ctx context.Context, client *proxyEtcdClient, in interface{}) (proto.Message, error) {
+	resp, err := client.Conn.Leave(ctx, in.(*cluster.LeaveRequest), client.CallOpts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range resp.Response {
+		if msg.Metadata == nil {
+			msg.Metadata = &common.NodeMetadata{}
+		}
+		msg.Metadata.Hostname = client.Target
+	}
+	return resp, nil
+}
+
+func (p *MultiserviceProxy) createNodeClient(ctx context.Context, targets []string, creds credentials.TransportCredentials, proxyMd metadata.MD, opts []grpc.CallOption) ([]*proxyNodeClient, func(), error) {
+	var errs []error
+	clients := make([]*proxyNodeClient, 0, len(targets))
+	releases := make([]func(), 0, len(targets))
+	for _, target := range targets {
+		c := &proxyNodeClient{
+			Context:  metadata.NewOutgoingContext(ctx, proxyMd),
+			Target:   target,
+			DialOpts: p.DialOptions,
+			CallOpts: opts,
+		}
+		dialTarget, err := runtime.DialTarget(target, p.DefaultPort)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		targetCreds, err := runtime.TargetCredentials(target, creds, p.CredentialsFor, p.VerifyTargetName)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		conn, release, err := p.Pool.Get(p.Dialer, dialTarget, targetCreds, c.DialOpts...)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		releases = append(releases, release)
+		c.Conn = node.NewNodeClient(conn)
+		clients = append(clients, c)
+	}
+	return clients, func() {
+		for _, release := range releases {
+			release()
+		}
+	}, runtime.JoinErrors(errs...)
+}
+
+func (p *MultiserviceProxy) createClusterClient(ctx context.Context, targets []string, creds credentials.TransportCredentials, proxyMd metadata.MD, opts []grpc.CallOption) ([]*proxyClusterClient, func(), error) {
+	var errs []error
+	clients := make([]*proxyClusterClient, 0, len(targets))
+	releases := make([]func(), 0, len(targets))
+	for _, target := range targets {
+		c := &proxyClusterClient{
+			Context:  metadata.NewOutgoingContext(ctx, proxyMd),
+			Target:   target,
+			DialOpts: p.DialOptions,
+			CallOpts: opts,
+		}
+		dialTarget, err := runtime.DialTarget(target, p.DefaultPort)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		targetCreds, err := runtime.TargetCredentials(target, creds, p.CredentialsFor, p.VerifyTargetName)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		conn, release, err := p.Pool.Get(p.Dialer, dialTarget, targetCreds, c.DialOpts...)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		releases = append(releases, release)
+		c.Conn = cluster.NewClusterClient(conn)
+		clients = append(clients, c)
+	}
+	return clients, func() {
+		for _, release := range releases {
+			release()
+		}
+	}, runtime.JoinErrors(errs...)
+}
+
+func (p *MultiserviceProxy) createEtcdClient(ctx context.Context, targets []string, creds credentials.TransportCredentials, proxyMd metadata.MD, opts []grpc.CallOption) ([]*proxyEtcdClient, func(), error) {
+	var errs []error
+	clients := make([]*proxyEtcdClient, 0, len(targets))
+	releases := make([]func(), 0, len(targets))
+	for _, target := range targets {
+		c := &proxyEtcdClient{
+			Context:  metadata.NewOutgoingContext(ctx, proxyMd),
+			Target:   target,
+			DialOpts: p.DialOptions,
+			CallOpts: opts,
+		}
+		dialTarget, err := runtime.DialTarget(target, p.DefaultPort)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		targetCreds, err := runtime.TargetCredentials(target, creds, p.CredentialsFor, p.VerifyTargetName)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		conn, release, err := p.Pool.Get(p.Dialer, dialTarget, targetCreds, c.DialOpts...)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		releases = append(releases, release)
+		c.Conn = cluster.NewEtcdClient(conn)
+		clients = append(clients, c)
+	}
+	return clients, func() {
+		for _, release := range releases {
+			release()
+		}
+	}, runtime.JoinErrors(errs...)
+}
+
+type Registrator struct {
+	node.NodeClient
+	node.UnimplementedNodeServer
+	cluster.ClusterClient
+	cluster.UnimplementedClusterServer
+	cluster.EtcdClient
+	cluster.UnimplementedEtcdServer
+}
+
+func (r *Registrator) Register(s *grpc.Server) {
+	node.RegisterNodeServer(s, r)
+	cluster.RegisterClusterServer(s, r)
+	cluster.RegisterEtcdServer(s, r)
+
+}
+
+func (r *Registrator) Hostname(ctx context.Context, in *emptypb.Empty) (*node.HostnameResponse, error) {
+	return r.NodeClient.Hostname(ctx, in)
+}
+func (r *Registrator) Uptime(ctx context.Context, in *node.UptimeRequest) (*node.UptimeResponse, error) {
+	return r.NodeClient.Uptime(ctx, in)
+}
+func (r *Registrator) Members(ctx context.Context, in *cluster.MembersRequest) (*cluster.MembersResponse, error) {
+	return r.ClusterClient.Members(ctx, in)
+}
+func (r *Registrator) Leave(ctx context.Context, in *cluster.LeaveRequest) (*cluster.LeaveResponse, error) {
+	return r.EtcdClient.Leave(ctx, in)
+}
+func (r *Registrator) Watch(in *cluster.WatchRequest, srv cluster.Etcd_WatchServer) error {
+	client, err := r.EtcdClient.Watch(srv.Context(), in)
+	if err != nil {
+		return err
+	}
+	return copyClientServer(func() interface{} { return new(cluster.Event) }, client, srv)
+}
+
+type LocalNodeClient struct {
+	node.NodeClient
+}
+
+func NewLocalNodeClient() (node.NodeClient, error) {
+	conn, err := grpc.Dial("unix:"+runtime.SocketPath("node.Node"),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalNodeClient{
+		NodeClient: node.NewNodeClient(conn),
+	}, nil
+}
+
+func (c *LocalNodeClient) Hostname(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*node.HostnameResponse, error) {
+	return c.NodeClient.Hostname(ctx, in, opts...)
+}
+func (c *LocalNodeClient) Uptime(ctx context.Context, in *node.UptimeRequest, opts ...grpc.CallOption) (*node.UptimeResponse, error) {
+	return c.NodeClient.Uptime(ctx, in, opts...)
+}
+
+type LocalClusterClient struct {
+	cluster.ClusterClient
+}
+
+func NewLocalClusterClient() (cluster.ClusterClient, error) {
+	conn, err := grpc.Dial("unix:"+runtime.SocketPath("cluster.Cluster"),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalClusterClient{
+		ClusterClient: cluster.NewClusterClient(conn),
+	}, nil
+}
+
+func (c *LocalClusterClient) Members(ctx context.Context, in *cluster.MembersRequest, opts ...grpc.CallOption) (*cluster.MembersResponse, error) {
+	return c.ClusterClient.Members(ctx, in, opts...)
+}
+
+type LocalEtcdClient struct {
+	cluster.EtcdClient
+}
+
+func NewLocalEtcdClient() (cluster.EtcdClient, error) {
+	conn, err := grpc.Dial("unix:"+runtime.SocketPath("cluster.Etcd"),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalEtcdClient{
+		EtcdClient: cluster.NewEtcdClient(conn),
+	}, nil
+}
+
+func (c *LocalEtcdClient) Leave(ctx context.Context, in *cluster.LeaveRequest, opts ...grpc.CallOption) (*cluster.LeaveResponse, error) {
+	return c.EtcdClient.Leave(ctx, in, opts...)
+}
+func (c *LocalEtcdClient) Watch(ctx context.Context, in *cluster.WatchRequest, opts ...grpc.CallOption) (cluster.Etcd_WatchClient, error) {
+	return c.EtcdClient.Watch(ctx, in, opts...)
+}
@@ -0,0 +1,346 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: node/node.proto
+
+package node
+
+import (
+	common "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/common"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Hostname struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metadata      *common.NodeMetadata   `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Hostname      string                 `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hostname) Reset() {
+	*x = Hostname{}
+	mi := &file_node_node_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hostname) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hostname) ProtoMessage() {}
+
+func (x *Hostname) ProtoReflect() protoreflect.Message {
+	mi := &file_node_node_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hostname.ProtoReflect.Descriptor instead.
+func (*Hostname) Descriptor() ([]byte, []int) {
+	return file_node_node_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Hostname) GetMetadata() *common.NodeMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Hostname) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+type HostnameResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Response      []*Hostname            `protobuf:"bytes,1,rep,name=response,proto3" json:"response,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostnameResponse) Reset() {
+	*x = HostnameResponse{}
+	mi := &file_node_node_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostnameResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostnameResponse) ProtoMessage() {}
+
+func (x *HostnameResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_node_node_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostnameResponse.ProtoReflect.Descriptor instead.
+func (*HostnameResponse) Descriptor() ([]byte, []int) {
+	return file_node_node_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HostnameResponse) GetResponse() []*Hostname {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+type UptimeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SinceBoot     *bool                  `protobuf:"varint,1,opt,name=since_boot,json=sinceBoot,proto3,oneof" json:"since_boot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UptimeRequest) Reset() {
+	*x = UptimeRequest{}
+	mi := &file_node_node_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UptimeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UptimeRequest) ProtoMessage() {}
+
+func (x *UptimeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_node_node_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UptimeRequest.ProtoReflect.Descriptor instead.
+func (*UptimeRequest) Descriptor() ([]byte, []int) {
+	return file_node_node_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UptimeRequest) GetSinceBoot() bool {
+	if x != nil && x.SinceBoot != nil {
+		return *x.SinceBoot
+	}
+	return false
+}
+
+type Uptime struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metadata      *common.NodeMetadata   `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Seconds       int64                  `protobuf:"varint,2,opt,name=seconds,proto3" json:"seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Uptime) Reset() {
+	*x = Uptime{}
+	mi := &file_node_node_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Uptime) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Uptime) ProtoMessage() {}
+
+func (x *Uptime) ProtoReflect() protoreflect.Message {
+	mi := &file_node_node_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Uptime.ProtoReflect.Descriptor instead.
+func (*Uptime) Descriptor() ([]byte, []int) {
+	return file_node_node_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Uptime) GetMetadata() *common.NodeMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Uptime) GetSeconds() int64 {
+	if x != nil {
+		return x.Seconds
+	}
+	return 0
+}
+
+type UptimeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Response      []*Uptime              `protobuf:"bytes,1,rep,name=response,proto3" json:"response,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UptimeResponse) Reset() {
+	*x = UptimeResponse{}
+	mi := &file_node_node_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UptimeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UptimeResponse) ProtoMessage() {}
+
+func (x *UptimeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_node_node_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UptimeResponse.ProtoReflect.Descriptor instead.
+func (*UptimeResponse) Descriptor() ([]byte, []int) {
+	return file_node_node_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UptimeResponse) GetResponse() []*Uptime {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+var File_node_node_proto protoreflect.FileDescriptor
+
+const file_node_node_proto_rawDesc = "" +
+	"\n" +
+	"\x0fnode/node.proto\x12\x04node\x1a\x1bgoogle/protobuf/empty.proto\x1a\x13common/common.proto\"X\n" +
+	"\bHostname\x120\n" +
+	"\bmetadata\x18\x01 \x01(\v2\x14.common.NodeMetadataR\bmetadata\x12\x1a\n" +
+	"\bhostname\x18\x02 \x01(\tR\bhostname\">\n" +
+	"\x10HostnameResponse\x12*\n" +
+	"\bresponse\x18\x01 \x03(\v2\x0e.node.HostnameR\bresponse\"B\n" +
+	"\rUptimeRequest\x12\"\n" +
+	"\n" +
+	"since_boot\x18\x01 \x01(\bH\x00R\tsinceBoot\x88\x01\x01B\r\n" +
+	"\v_since_boot\"T\n" +
+	"\x06Uptime\x120\n" +
+	"\bmetadata\x18\x01 \x01(\v2\x14.common.NodeMetadataR\bmetadata\x12\x18\n" +
+	"\aseconds\x18\x02 \x01(\x03R\aseconds\":\n" +
+	"\x0eUptimeResponse\x12(\n" +
+	"\bresponse\x18\x01 \x03(\v2\f.node.UptimeR\bresponse2w\n" +
+	"\x04Node\x12:\n" +
+	"\bHostname\x12\x16.google.protobuf.Empty\x1a\x16.node.HostnameResponse\x123\n" +
+	"\x06Uptime\x12\x13.node.UptimeRequest\x1a\x14.node.UptimeResponseBGZEgithub.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/nodeb\x06proto3"
+
+var (
+	file_node_node_proto_rawDescOnce sync.Once
+	file_node_node_proto_rawDescData []byte
+)
+
+func file_node_node_proto_rawDescGZIP() []byte {
+	file_node_node_proto_rawDescOnce.Do(func() {
+		file_node_node_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_node_node_proto_rawDesc), len(file_node_node_proto_rawDesc)))
+	})
+	return file_node_node_proto_rawDescData
+}
+
+var file_node_node_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_node_node_proto_goTypes = []any{
+	(*Hostname)(nil),            // 0: node.Hostname
+	(*HostnameResponse)(nil),    // 1: node.HostnameResponse
+	(*UptimeRequest)(nil),       // 2: node.UptimeRequest
+	(*Uptime)(nil),              // 3: node.Uptime
+	(*UptimeResponse)(nil),      // 4: node.UptimeResponse
+	(*common.NodeMetadata)(nil), // 5: common.NodeMetadata
+	(*emptypb.Empty)(nil),       // 6: google.protobuf.Empty
+}
+var file_node_node_proto_depIdxs = []int32{
+	5, // 0: node.Hostname.metadata:type_name -> common.NodeMetadata
+	0, // 1: node.HostnameResponse.response:type_name -> node.Hostname
+	5, // 2: node.Uptime.metadata:type_name -> common.NodeMetadata
+	3, // 3: node.UptimeResponse.response:type_name -> node.Uptime
+	6, // 4: node.Node.Hostname:input_type -> google.protobuf.Empty
+	2, // 5: node.Node.Uptime:input_type -> node.UptimeRequest
+	1, // 6: node.Node.Hostname:output_type -> node.HostnameResponse
+	4, // 7: node.Node.Uptime:output_type -> node.UptimeResponse
+	6, // [6:8] is the sub-list for method output_type
+	4, // [4:6] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_node_node_proto_init() }
+func file_node_node_proto_init() {
+	if File_node_node_proto != nil {
+		return
+	}
+	file_node_node_proto_msgTypes[2].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_node_node_proto_rawDesc), len(file_node_node_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_node_node_proto_goTypes,
+		DependencyIndexes: file_node_node_proto_depIdxs,
+		MessageInfos:      file_node_node_proto_msgTypes,
+	}.Build()
+	File_node_node_proto = out.File
+	file_node_node_proto_goTypes = nil
+	file_node_node_proto_depIdxs = nil
+}
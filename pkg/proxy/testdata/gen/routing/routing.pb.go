@@ -0,0 +1,329 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: routing/routing.proto
+
+package routing
+
+import (
+	common "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/common"
+	_ "github.com/talos-systems/protoc-gen-proxy/proxy"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Reply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metadata      *common.NodeMetadata   `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reply) Reset() {
+	*x = Reply{}
+	mi := &file_routing_routing_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reply) ProtoMessage() {}
+
+func (x *Reply) ProtoReflect() protoreflect.Message {
+	mi := &file_routing_routing_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reply.ProtoReflect.Descriptor instead.
+func (*Reply) Descriptor() ([]byte, []int) {
+	return file_routing_routing_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Reply) GetMetadata() *common.NodeMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Reply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type FanoutResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Messages      []*Reply               `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FanoutResponse) Reset() {
+	*x = FanoutResponse{}
+	mi := &file_routing_routing_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FanoutResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FanoutResponse) ProtoMessage() {}
+
+func (x *FanoutResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_routing_routing_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FanoutResponse.ProtoReflect.Descriptor instead.
+func (*FanoutResponse) Descriptor() ([]byte, []int) {
+	return file_routing_routing_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FanoutResponse) GetMessages() []*Reply {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+type Stat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          *common.NodeMetadata   `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Value         uint64                 `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Stat) Reset() {
+	*x = Stat{}
+	mi := &file_routing_routing_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stat) ProtoMessage() {}
+
+func (x *Stat) ProtoReflect() protoreflect.Message {
+	mi := &file_routing_routing_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stat.ProtoReflect.Descriptor instead.
+func (*Stat) Descriptor() ([]byte, []int) {
+	return file_routing_routing_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Stat) GetNode() *common.NodeMetadata {
+	if x != nil {
+		return x.Node
+	}
+	return nil
+}
+
+func (x *Stat) GetValue() uint64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type StatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stats         []*Stat                `protobuf:"bytes,1,rep,name=stats,proto3" json:"stats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_routing_routing_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_routing_routing_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_routing_routing_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StatsResponse) GetStats() []*Stat {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+var File_routing_routing_proto protoreflect.FileDescriptor
+
+const file_routing_routing_proto_rawDesc = "" +
+	"\n" +
+	"\x15routing/routing.proto\x12\arouting\x1a\x1bgoogle/protobuf/empty.proto\x1a\x13common/common.proto\x1a\x13proxy/options.proto\"S\n" +
+	"\x05Reply\x120\n" +
+	"\bmetadata\x18\x01 \x01(\v2\x14.common.NodeMetadataR\bmetadata\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"<\n" +
+	"\x0eFanoutResponse\x12*\n" +
+	"\bmessages\x18\x01 \x03(\v2\x0e.routing.ReplyR\bmessages\"F\n" +
+	"\x04Stat\x12(\n" +
+	"\x04node\x18\x01 \x01(\v2\x14.common.NodeMetadataR\x04node\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value\"4\n" +
+	"\rStatsResponse\x12#\n" +
+	"\x05stats\x18\x01 \x03(\v2\r.routing.StatR\x05stats2\xa0\x06\n" +
+	"\aRouting\x129\n" +
+	"\x06Fanout\x12\x16.google.protobuf.Empty\x1a\x17.routing.FanoutResponse\x12O\n" +
+	"\x06Single\x12\x16.google.protobuf.Empty\x1a\x0e.routing.Reply\"\x1d\x82\x80\x19\x19\b\x01\"\bos:admin\"\vos:operator\x127\n" +
+	"\x05Local\x12\x16.google.protobuf.Empty\x1a\x0e.routing.Reply\"\x06\x82\x80\x19\x02\b\x02\x129\n" +
+	"\aSkipped\x12\x16.google.protobuf.Empty\x1a\x0e.routing.Reply\"\x06\x82\x80\x19\x02\b\x03\x12c\n" +
+	"\x05Stats\x12\x16.google.protobuf.Empty\x1a\x16.routing.StatsResponse\"*\x82\x80\x19&\x12\r\n" +
+	"\x05stats\x12\x04node\x1a\f\b\x02\"\b\b\x01\x10\x80ʵ\xee\x01*\a\b\x80\x80\x80\b\x18\x01\x12J\n" +
+	"\aPartial\x12\x16.google.protobuf.Empty\x1a\x17.routing.FanoutResponse\"\x0e\x82\x80\x19\n" +
+	"\x12\x02\x18\x01\x1a\x04\x10\x01\x18\x01\x12@\n" +
+	"\x03Any\x12\x16.google.protobuf.Empty\x1a\x17.routing.FanoutResponse\"\b\x82\x80\x19\x04\x1a\x02(\x01\x12C\n" +
+	"\x06Quorum\x12\x16.google.protobuf.Empty\x1a\x17.routing.FanoutResponse\"\b\x82\x80\x19\x04\x1a\x02(\x02\x12D\n" +
+	"\x05OneOf\x12\x16.google.protobuf.Empty\x1a\x17.routing.FanoutResponse\"\n" +
+	"\x82\x80\x19\x06\x1a\x04(\x030\x01\x12I\n" +
+	"\bMajority\x12\x16.google.protobuf.Empty\x1a\x17.routing.FanoutResponse\"\f\x82\x80\x19\b\x12\x02\x18\x01\x1a\x02(\x02\x12:\n" +
+	"\x06Events\x12\x16.google.protobuf.Empty\x1a\x0e.routing.Reply\"\x06\x82\x80\x19\x02\b\x020\x01\x1a\x10\x82\x80\x19\f\x12\n" +
+	"\n" +
+	"\bmessagesBJZHgithub.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/routingb\x06proto3"
+
+var (
+	file_routing_routing_proto_rawDescOnce sync.Once
+	file_routing_routing_proto_rawDescData []byte
+)
+
+func file_routing_routing_proto_rawDescGZIP() []byte {
+	file_routing_routing_proto_rawDescOnce.Do(func() {
+		file_routing_routing_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_routing_routing_proto_rawDesc), len(file_routing_routing_proto_rawDesc)))
+	})
+	return file_routing_routing_proto_rawDescData
+}
+
+var file_routing_routing_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_routing_routing_proto_goTypes = []any{
+	(*Reply)(nil),               // 0: routing.Reply
+	(*FanoutResponse)(nil),      // 1: routing.FanoutResponse
+	(*Stat)(nil),                // 2: routing.Stat
+	(*StatsResponse)(nil),       // 3: routing.StatsResponse
+	(*common.NodeMetadata)(nil), // 4: common.NodeMetadata
+	(*emptypb.Empty)(nil),       // 5: google.protobuf.Empty
+}
+var file_routing_routing_proto_depIdxs = []int32{
+	4,  // 0: routing.Reply.metadata:type_name -> common.NodeMetadata
+	0,  // 1: routing.FanoutResponse.messages:type_name -> routing.Reply
+	4,  // 2: routing.Stat.node:type_name -> common.NodeMetadata
+	2,  // 3: routing.StatsResponse.stats:type_name -> routing.Stat
+	5,  // 4: routing.Routing.Fanout:input_type -> google.protobuf.Empty
+	5,  // 5: routing.Routing.Single:input_type -> google.protobuf.Empty
+	5,  // 6: routing.Routing.Local:input_type -> google.protobuf.Empty
+	5,  // 7: routing.Routing.Skipped:input_type -> google.protobuf.Empty
+	5,  // 8: routing.Routing.Stats:input_type -> google.protobuf.Empty
+	5,  // 9: routing.Routing.Partial:input_type -> google.protobuf.Empty
+	5,  // 10: routing.Routing.Any:input_type -> google.protobuf.Empty
+	5,  // 11: routing.Routing.Quorum:input_type -> google.protobuf.Empty
+	5,  // 12: routing.Routing.OneOf:input_type -> google.protobuf.Empty
+	5,  // 13: routing.Routing.Majority:input_type -> google.protobuf.Empty
+	5,  // 14: routing.Routing.Events:input_type -> google.protobuf.Empty
+	1,  // 15: routing.Routing.Fanout:output_type -> routing.FanoutResponse
+	0,  // 16: routing.Routing.Single:output_type -> routing.Reply
+	0,  // 17: routing.Routing.Local:output_type -> routing.Reply
+	0,  // 18: routing.Routing.Skipped:output_type -> routing.Reply
+	3,  // 19: routing.Routing.Stats:output_type -> routing.StatsResponse
+	1,  // 20: routing.Routing.Partial:output_type -> routing.FanoutResponse
+	1,  // 21: routing.Routing.Any:output_type -> routing.FanoutResponse
+	1,  // 22: routing.Routing.Quorum:output_type -> routing.FanoutResponse
+	1,  // 23: routing.Routing.OneOf:output_type -> routing.FanoutResponse
+	1,  // 24: routing.Routing.Majority:output_type -> routing.FanoutResponse
+	0,  // 25: routing.Routing.Events:output_type -> routing.Reply
+	15, // [15:26] is the sub-list for method output_type
+	4,  // [4:15] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_routing_routing_proto_init() }
+func file_routing_routing_proto_init() {
+	if File_routing_routing_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_routing_routing_proto_rawDesc), len(file_routing_routing_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_routing_routing_proto_goTypes,
+		DependencyIndexes: file_routing_routing_proto_depIdxs,
+		MessageInfos:      file_routing_routing_proto_msgTypes,
+	}.Build()
+	File_routing_routing_proto = out.File
+	file_routing_routing_proto_goTypes = nil
+	file_routing_routing_proto_depIdxs = nil
+}
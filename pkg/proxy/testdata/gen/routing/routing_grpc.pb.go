@@ -0,0 +1,506 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: routing/routing.proto
+
+package routing
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Routing_Fanout_FullMethodName   = "/routing.Routing/Fanout"
+	Routing_Single_FullMethodName   = "/routing.Routing/Single"
+	Routing_Local_FullMethodName    = "/routing.Routing/Local"
+	Routing_Skipped_FullMethodName  = "/routing.Routing/Skipped"
+	Routing_Stats_FullMethodName    = "/routing.Routing/Stats"
+	Routing_Partial_FullMethodName  = "/routing.Routing/Partial"
+	Routing_Any_FullMethodName      = "/routing.Routing/Any"
+	Routing_Quorum_FullMethodName   = "/routing.Routing/Quorum"
+	Routing_OneOf_FullMethodName    = "/routing.Routing/OneOf"
+	Routing_Majority_FullMethodName = "/routing.Routing/Majority"
+	Routing_Events_FullMethodName   = "/routing.Routing/Events"
+)
+
+// RoutingClient is the client API for Routing service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RoutingClient interface {
+	Fanout(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FanoutResponse, error)
+	Single(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Reply, error)
+	Local(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Reply, error)
+	Skipped(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Reply, error)
+	Stats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatsResponse, error)
+	Partial(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FanoutResponse, error)
+	Any(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FanoutResponse, error)
+	Quorum(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FanoutResponse, error)
+	OneOf(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FanoutResponse, error)
+	Majority(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FanoutResponse, error)
+	Events(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Reply], error)
+}
+
+type routingClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRoutingClient(cc grpc.ClientConnInterface) RoutingClient {
+	return &routingClient{cc}
+}
+
+func (c *routingClient) Fanout(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FanoutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FanoutResponse)
+	err := c.cc.Invoke(ctx, Routing_Fanout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingClient) Single(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Reply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reply)
+	err := c.cc.Invoke(ctx, Routing_Single_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingClient) Local(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Reply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reply)
+	err := c.cc.Invoke(ctx, Routing_Local_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingClient) Skipped(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Reply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reply)
+	err := c.cc.Invoke(ctx, Routing_Skipped_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingClient) Stats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, Routing_Stats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingClient) Partial(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FanoutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FanoutResponse)
+	err := c.cc.Invoke(ctx, Routing_Partial_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingClient) Any(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FanoutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FanoutResponse)
+	err := c.cc.Invoke(ctx, Routing_Any_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingClient) Quorum(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FanoutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FanoutResponse)
+	err := c.cc.Invoke(ctx, Routing_Quorum_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingClient) OneOf(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FanoutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FanoutResponse)
+	err := c.cc.Invoke(ctx, Routing_OneOf_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingClient) Majority(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FanoutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FanoutResponse)
+	err := c.cc.Invoke(ctx, Routing_Majority_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingClient) Events(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Reply], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Routing_ServiceDesc.Streams[0], Routing_Events_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[emptypb.Empty, Reply]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Routing_EventsClient = grpc.ServerStreamingClient[Reply]
+
+// RoutingServer is the server API for Routing service.
+// All implementations must embed UnimplementedRoutingServer
+// for forward compatibility.
+type RoutingServer interface {
+	Fanout(context.Context, *emptypb.Empty) (*FanoutResponse, error)
+	Single(context.Context, *emptypb.Empty) (*Reply, error)
+	Local(context.Context, *emptypb.Empty) (*Reply, error)
+	Skipped(context.Context, *emptypb.Empty) (*Reply, error)
+	Stats(context.Context, *emptypb.Empty) (*StatsResponse, error)
+	Partial(context.Context, *emptypb.Empty) (*FanoutResponse, error)
+	Any(context.Context, *emptypb.Empty) (*FanoutResponse, error)
+	Quorum(context.Context, *emptypb.Empty) (*FanoutResponse, error)
+	OneOf(context.Context, *emptypb.Empty) (*FanoutResponse, error)
+	Majority(context.Context, *emptypb.Empty) (*FanoutResponse, error)
+	Events(*emptypb.Empty, grpc.ServerStreamingServer[Reply]) error
+	mustEmbedUnimplementedRoutingServer()
+}
+
+// UnimplementedRoutingServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRoutingServer struct{}
+
+func (UnimplementedRoutingServer) Fanout(context.Context, *emptypb.Empty) (*FanoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Fanout not implemented")
+}
+func (UnimplementedRoutingServer) Single(context.Context, *emptypb.Empty) (*Reply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Single not implemented")
+}
+func (UnimplementedRoutingServer) Local(context.Context, *emptypb.Empty) (*Reply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Local not implemented")
+}
+func (UnimplementedRoutingServer) Skipped(context.Context, *emptypb.Empty) (*Reply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Skipped not implemented")
+}
+func (UnimplementedRoutingServer) Stats(context.Context, *emptypb.Empty) (*StatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
+}
+func (UnimplementedRoutingServer) Partial(context.Context, *emptypb.Empty) (*FanoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Partial not implemented")
+}
+func (UnimplementedRoutingServer) Any(context.Context, *emptypb.Empty) (*FanoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Any not implemented")
+}
+func (UnimplementedRoutingServer) Quorum(context.Context, *emptypb.Empty) (*FanoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Quorum not implemented")
+}
+func (UnimplementedRoutingServer) OneOf(context.Context, *emptypb.Empty) (*FanoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OneOf not implemented")
+}
+func (UnimplementedRoutingServer) Majority(context.Context, *emptypb.Empty) (*FanoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Majority not implemented")
+}
+func (UnimplementedRoutingServer) Events(*emptypb.Empty, grpc.ServerStreamingServer[Reply]) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedRoutingServer) mustEmbedUnimplementedRoutingServer() {}
+func (UnimplementedRoutingServer) testEmbeddedByValue()                 {}
+
+// UnsafeRoutingServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RoutingServer will
+// result in compilation errors.
+type UnsafeRoutingServer interface {
+	mustEmbedUnimplementedRoutingServer()
+}
+
+func RegisterRoutingServer(s grpc.ServiceRegistrar, srv RoutingServer) {
+	// If the following call pancis, it indicates UnimplementedRoutingServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Routing_ServiceDesc, srv)
+}
+
+func _Routing_Fanout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServer).Fanout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Routing_Fanout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServer).Fanout(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Routing_Single_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServer).Single(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Routing_Single_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServer).Single(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Routing_Local_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServer).Local(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Routing_Local_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServer).Local(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Routing_Skipped_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServer).Skipped(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Routing_Skipped_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServer).Skipped(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Routing_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Routing_Stats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServer).Stats(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Routing_Partial_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServer).Partial(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Routing_Partial_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServer).Partial(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Routing_Any_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServer).Any(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Routing_Any_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServer).Any(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Routing_Quorum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServer).Quorum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Routing_Quorum_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServer).Quorum(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Routing_OneOf_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServer).OneOf(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Routing_OneOf_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServer).OneOf(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Routing_Majority_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServer).Majority(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Routing_Majority_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServer).Majority(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Routing_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RoutingServer).Events(m, &grpc.GenericServerStream[emptypb.Empty, Reply]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Routing_EventsServer = grpc.ServerStreamingServer[Reply]
+
+// Routing_ServiceDesc is the grpc.ServiceDesc for Routing service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Routing_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "routing.Routing",
+	HandlerType: (*RoutingServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Fanout",
+			Handler:    _Routing_Fanout_Handler,
+		},
+		{
+			MethodName: "Single",
+			Handler:    _Routing_Single_Handler,
+		},
+		{
+			MethodName: "Local",
+			Handler:    _Routing_Local_Handler,
+		},
+		{
+			MethodName: "Skipped",
+			Handler:    _Routing_Skipped_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _Routing_Stats_Handler,
+		},
+		{
+			MethodName: "Partial",
+			Handler:    _Routing_Partial_Handler,
+		},
+		{
+			MethodName: "Any",
+			Handler:    _Routing_Any_Handler,
+		},
+		{
+			MethodName: "Quorum",
+			Handler:    _Routing_Quorum_Handler,
+		},
+		{
+			MethodName: "OneOf",
+			Handler:    _Routing_OneOf_Handler,
+		},
+		{
+			MethodName: "Majority",
+			Handler:    _Routing_Majority_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			Handler:       _Routing_Events_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "routing/routing.proto",
+}
@@ -0,0 +1,61 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: streaming.proto
+
+package streaming
+
+import (
+	_ "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/logs"
+	_ "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/transfer"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var File_streaming_proto protoreflect.FileDescriptor
+
+const file_streaming_proto_rawDesc = "" +
+	"\n" +
+	"\x0fstreaming.proto\x12\tstreaming\x1a\x0flogs/logs.proto\x1a\x17transfer/transfer.protoBLZJgithub.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/streamingb\x06proto3"
+
+var file_streaming_proto_goTypes = []any{}
+var file_streaming_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_streaming_proto_init() }
+func file_streaming_proto_init() {
+	if File_streaming_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_streaming_proto_rawDesc), len(file_streaming_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_streaming_proto_goTypes,
+		DependencyIndexes: file_streaming_proto_depIdxs,
+	}.Build()
+	File_streaming_proto = out.File
+	file_streaming_proto_goTypes = nil
+	file_streaming_proto_depIdxs = nil
+}
@@ -0,0 +1,699 @@
+// Code generated by protoc-gen-proxy. DO NOT EDIT.
+// source: streaming.proto
+
+package streaming
+
+import (
+	context "context"
+	common "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/common"
+	logs "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/logs"
+	transfer "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/transfer"
+	runtime "github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	credentials "google.golang.org/grpc/credentials"
+	metadata "google.golang.org/grpc/metadata"
+	status "google.golang.org/grpc/status"
+	proto "google.golang.org/protobuf/proto"
+	io "io"
+)
+
+type StreamingProxy struct {
+	Provider runtime.CertificateProvider
+	// Credentials caches the credentials built from the Provider until it
+	// signals a rotation, a nil Credentials builds them on each request.
+	Credentials *runtime.CredentialsCache
+	// CredentialsFor returns the credentials to dial a target with, e.g. to
+	// trust the CA of its cluster. The targets it returns nil credentials
+	// for, or all of them when unset, are dialed with the Credentials.
+	CredentialsFor runtime.CredentialsFunc
+	// VerifyTargetName requires the certificate of each target to be valid
+	// for the host it was requested as.
+	VerifyTargetName bool
+	// Name identifies the proxy in the route of the requests, it defaults to
+	// the authority of each request.
+	Name string
+	// MaxHops is the number of proxies a request may go through, the
+	// requests which went through as many get handled locally. Zero only
+	// lets a single proxy forward them.
+	MaxHops int
+	// DefaultPort is dialed when a target doesn't specify a port.
+	DefaultPort int
+	// Pool holds the connections to the targets, a nil Pool dials them on
+	// each request.
+	Pool *runtime.Pool
+	// DialOptions are applied when dialing the targets, e.g. keepalives,
+	// message sizes or a custom dialer. The pooled connections keep the
+	// options they were dialed with.
+	DialOptions []grpc.DialOption
+	// Dialer creates the connections to the targets, e.g. to in-memory
+	// servers in tests with a runtimetest.BufconnDialer. The targets are dialed
+	// over the network when unset.
+	Dialer runtime.Dialer
+	// Balancer spreads the methods dispatched to one of their targets, it
+	// tracks the calls in flight to each target.
+	Balancer *runtime.Balancer
+	// ForwardedMetadata lists the keys of the incoming metadata passed on to
+	// the targets.
+	ForwardedMetadata []string
+	// Resolver expands the logical targets of the requests, such as "all",
+	// into the addresses of the nodes. The targets are used as is when unset.
+	Resolver runtime.Resolver
+	// TargetPolicy restricts the targets the requests may ask for, any
+	// target is allowed when unset.
+	TargetPolicy *runtime.TargetPolicy
+	// Authorizer decides whether the requests get proxied and may narrow
+	// their targets, once the caller is checked against the roles of the
+	// method.
+	Authorizer runtime.Authorizer
+	// UnaryFallback and StreamFallback handle the methods of the proxied
+	// services the proxy doesn't route, those fail with Unimplemented when
+	// unset. runtime.LocalUnaryFallback and runtime.LocalStreamFallback
+	// hand them over to the local server.
+	UnaryFallback  grpc.UnaryServerInterceptor
+	StreamFallback grpc.StreamServerInterceptor
+}
+
+func NewStreamingProxy(provider runtime.CertificateProvider) *StreamingProxy {
+	return &StreamingProxy{
+		Provider:    provider,
+		Credentials: runtime.NewCredentialsCache(),
+		Pool:        runtime.NewPool(runtime.DefaultIdleTimeout),
+		Balancer:    runtime.NewBalancer(),
+	}
+}
+
+// Close closes the connections to the targets.
+func (p *StreamingProxy) Close() error {
+	return p.Pool.Close()
+}
+
+func proxiedService(fullMethod string) bool {
+	switch runtime.ServiceName(fullMethod) {
+	case "logs.Logs", "transfer.Transfer":
+		return true
+	}
+	return false
+}
+
+func routedMethod(fullMethod string) bool {
+	switch fullMethod {
+	case "/logs.Logs/Sources", "/logs.Logs/Tail", "/transfer.Transfer/Upload", "/transfer.Transfer/Sync":
+		return true
+	}
+	return false
+}
+
+func methodRoles(fullMethod string) []string {
+	return nil
+}
+
+func (p *StreamingProxy) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if !proxiedService(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		// the roles are checked whether the request is proxied or not, the
+		// route metadata being set by the callers
+		if err := runtime.CheckRoles(ctx, info.FullMethod, methodRoles(info.FullMethod)); err != nil {
+			return nil, err
+		}
+		forward, err := runtime.Forward(md, p.Name, p.MaxHops)
+		if err != nil {
+			return nil, err
+		}
+		if !forward {
+			return handler(ctx, req)
+		}
+		if !routedMethod(info.FullMethod) {
+			if p.UnaryFallback != nil {
+				return p.UnaryFallback(ctx, req, info, handler)
+			}
+			return nil, runtime.ErrUnknownMethod
+		}
+		creds, err := p.Credentials.Get(p.Provider)
+		if err != nil {
+			return nil, err
+		}
+		return p.UnaryProxy(ctx, info.FullMethod, creds, req)
+	}
+}
+
+func (p *StreamingProxy) UnaryProxy(ctx context.Context, method string, creds credentials.TransportCredentials, in interface{}, opts ...grpc.CallOption) (proto.Message, error) {
+	var (
+		err      error
+		msgs     []proto.Message
+		response proto.Message
+		targets  []string
+	)
+	md, _ := metadata.FromIncomingContext(ctx)
+	// the requests without targets are meant for the proxy node itself, they
+	// are rejected rather than proxied to its own authority
+	targets = md["targets"]
+	if p.Resolver != nil {
+		if targets, err = p.Resolver.Resolve(ctx, targets); err != nil {
+			return nil, err
+		}
+	}
+	// the targets are checked before any of them gets dialed
+	if err = p.TargetPolicy.Check(ctx, method, targets); err != nil {
+		return nil, err
+	}
+	if targets, err = runtime.Authorize(ctx, p.Authorizer, method, methodRoles(method), targets, in); err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no targets to proxy to")
+	}
+	proxyMd := runtime.ForwardMetadata(md, p.ForwardedMetadata)
+	runtime.SetVia(proxyMd, md, p.Name)
+
+	switch method {
+	case "/logs.Logs/Sources":
+		execution := runtime.Execution{Balancer: p.Balancer}
+		if err = execution.ApplyMetadata(md); err != nil {
+			break
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createLogsClient(ctx, targets, creds, proxyMd, opts)
+		defer release()
+		resp := &logs.SourcesResponse{}
+		msgs, err = proxyLogsRunner(metadata.NewOutgoingContext(ctx, proxyMd), clients, in, proxySources, execution)
+		for _, msg := range msgs {
+			resp.Response = append(resp.Response, msg.(*logs.SourcesResponse).Response...)
+		}
+		response = resp
+		// the targets which couldn't be dialed failed as well
+		err = runtime.JoinErrors(dialErr, err)
+
+	default:
+		return nil, runtime.ErrUnknownMethod
+	}
+
+	return response, err
+}
+func copyClientServer(newMsg func() interface{}, client grpc.ClientStream, srv grpc.ServerStream) error {
+	for {
+		msg := newMsg()
+		err := client.RecvMsg(msg)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = srv.SendMsg(msg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyServerClient(newMsg func() interface{}, srv grpc.ServerStream, client grpc.ClientStream) error {
+	for {
+		msg := newMsg()
+		err := srv.RecvMsg(msg)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = client.SendMsg(msg)
+		if err == io.EOF {
+			// the stream got aborted, the status is returned by RecvMsg
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyBidiStream(newIn, newOut func() interface{}, srv grpc.ServerStream, client grpc.ClientStream, cancel context.CancelFunc) error {
+	errCh := make(chan error, 1)
+
+	go func() {
+		err := copyServerClient(newIn, srv, client)
+		if err != nil {
+			// abort the proxied stream
+			cancel()
+		} else {
+			// half-close, the responses keep flowing
+			err = client.CloseSend()
+		}
+
+		errCh <- err
+	}()
+
+	if err := copyClientServer(newOut, client, srv); err != nil {
+		select {
+		case inErr := <-errCh:
+			if inErr != nil {
+				return inErr
+			}
+		default:
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (p *StreamingProxy) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		if !proxiedService(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		// the roles are checked whether the request is proxied or not, the
+		// route metadata being set by the callers
+		if err := runtime.CheckRoles(ss.Context(), info.FullMethod, methodRoles(info.FullMethod)); err != nil {
+			return err
+		}
+		forward, err := runtime.Forward(md, p.Name, p.MaxHops)
+		if err != nil {
+			return err
+		}
+		if !forward {
+			return handler(srv, ss)
+		}
+		if !routedMethod(info.FullMethod) {
+			if p.StreamFallback != nil {
+				return p.StreamFallback(srv, ss, info, handler)
+			}
+			return runtime.ErrUnknownMethod
+		}
+		creds, err := p.Credentials.Get(p.Provider)
+		if err != nil {
+			return err
+		}
+		return p.StreamProxy(ss, info.FullMethod, creds, srv)
+	}
+}
+
+func (p *StreamingProxy) StreamProxy(ss grpc.ServerStream, method string, creds credentials.TransportCredentials, srv interface{}, opts ...grpc.CallOption) error {
+	var (
+		err     error
+		targets []string
+		in      interface{}
+	)
+
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	// the requests without targets are meant for the proxy node itself, they
+	// are rejected rather than proxied to its own authority
+	targets = md["targets"]
+	if p.Resolver != nil {
+		if targets, err = p.Resolver.Resolve(ss.Context(), targets); err != nil {
+			return err
+		}
+	}
+	// the targets are checked before any of them gets dialed
+	if err = p.TargetPolicy.Check(ss.Context(), method, targets); err != nil {
+		return err
+	}
+	// the request of the server streams is received first for the
+	// authorizer to see it
+	switch method {
+	case "/logs.Logs/Tail":
+		m := new(logs.TailRequest)
+		if err = ss.RecvMsg(m); err != nil {
+			return err
+		}
+		in = m
+
+	}
+	if targets, err = runtime.Authorize(ss.Context(), p.Authorizer, method, methodRoles(method), targets, in); err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return status.Error(codes.InvalidArgument, "no targets to proxy to")
+	}
+
+	proxyMd := runtime.ForwardMetadata(md, p.ForwardedMetadata)
+	runtime.SetVia(proxyMd, md, p.Name)
+
+	switch method {
+	case "/logs.Logs/Tail":
+		// Initialize target clients
+		clients, release, dialErr := p.createLogsClient(ss.Context(), targets, creds, proxyMd, opts)
+		defer release()
+		if len(clients) == 0 {
+			err = dialErr
+			break
+		}
+		m := in.(*logs.TailRequest)
+		sources := make([]runtime.StreamSource, 0, len(clients))
+		for _, client := range clients {
+			client := client
+			sources = append(sources, runtime.StreamSource{
+				Target:  client.Target,
+				Context: client.Context,
+				Open: func(ctx context.Context) (grpc.ClientStream, error) {
+					return client.Conn.Tail(ctx, m, client.CallOpts...)
+				},
+			})
+		}
+		err = runtime.JoinErrors(dialErr, runtime.MergeStreams(ss, sources, func() proto.Message { return new(logs.LogEntry) }, func(msg proto.Message, target string) {
+			m := msg.(*logs.LogEntry)
+			if m.Metadata == nil {
+				m.Metadata = &common.NodeMetadata{}
+			}
+			m.Metadata.Hostname = target
+		}))
+	case "/transfer.Transfer/Upload":
+		opts = append(opts[:len(opts):len(opts)], grpc.MaxCallSendMsgSize(33554432), grpc.UseCompressor("gzip"))
+		if len(targets) > 1 {
+			targets = targets[:1]
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createTransferClient(ss.Context(), targets, creds, proxyMd, opts)
+		defer release()
+		if dialErr != nil {
+			return dialErr
+		}
+		clientStream, err := clients[0].Conn.Upload(clients[0].Context, clients[0].CallOpts...)
+		if err != nil {
+			return err
+		}
+		if err = copyServerClient(func() interface{} { return new(transfer.Chunk) }, ss, clientStream); err != nil {
+			return err
+		}
+		resp, err := clientStream.CloseAndRecv()
+		if err != nil {
+			return err
+		}
+		return ss.SendMsg(resp)
+	case "/transfer.Transfer/Sync":
+		opts = append(opts[:len(opts):len(opts)], grpc.MaxCallSendMsgSize(33554432), grpc.UseCompressor("gzip"))
+		if len(targets) > 1 {
+			targets = targets[:1]
+		}
+		// Initialize target clients
+		clients, release, dialErr := p.createTransferClient(ss.Context(), targets, creds, proxyMd, opts)
+		defer release()
+		if dialErr != nil {
+			return dialErr
+		}
+		ctx, cancel := context.WithCancel(clients[0].Context)
+		defer cancel()
+		clientStream, err := clients[0].Conn.Sync(ctx, clients[0].CallOpts...)
+		if err != nil {
+			return err
+		}
+		return copyBidiStream(func() interface{} { return new(transfer.Chunk) }, func() interface{} { return new(transfer.Ack) }, ss, clientStream, cancel)
+
+	default:
+		return runtime.ErrUnknownMethod
+	}
+
+	return err
+}
+
+// fanOutTargets returns the targets of a call made through the FanOut
+// methods, along with the credentials to dial them with and the metadata
+// to send them.
+func (p *StreamingProxy) fanOutTargets(ctx context.Context, method string, targets []string) ([]string, credentials.TransportCredentials, metadata.MD, error) {
+	var err error
+	if p.Resolver != nil {
+		if targets, err = p.Resolver.Resolve(ctx, targets); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if err = p.TargetPolicy.Check(ctx, method, targets); err != nil {
+		return nil, nil, nil, err
+	}
+	creds, err := p.Credentials.Get(p.Provider)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	proxyMd := runtime.ForwardMetadata(md, p.ForwardedMetadata)
+	runtime.SetVia(proxyMd, md, p.Name)
+	return targets, creds, proxyMd, nil
+}
+
+// FanOutSources calls Sources on the targets the way the proxied
+// requests are, it returns the responses and the errors keyed by target.
+// The errors which aren't tied to a target, e.g. without any target, are
+// keyed by an empty target. The roles of the method and the Authorizer
+// aren't checked, the calls being made by the process itself.
+func (p *StreamingProxy) FanOutSources(ctx context.Context, targets []string, in *logs.SourcesRequest, opts ...grpc.CallOption) (map[string]*logs.SourcesResponse, map[string]error) {
+	resolved, creds, proxyMd, err := p.fanOutTargets(ctx, "/logs.Logs/Sources", targets)
+	if err != nil {
+		return nil, runtime.ErrorForTargets(targets, err)
+	}
+	execution := runtime.Execution{Balancer: p.Balancer}
+	clients, release, dialErr := p.createLogsClient(ctx, resolved, creds, proxyMd, opts)
+	defer release()
+	clientTargets := make([]string, len(clients))
+	for i, client := range clients {
+		clientTargets[i] = client.Target
+	}
+	msgs, errs := execution.RunTargets(metadata.NewOutgoingContext(ctx, proxyMd), clientTargets, func(ctx context.Context, i int) (proto.Message, error) {
+		return proxySources(ctx, clients[i], in)
+	})
+	// the targets which couldn't be dialed failed as well
+	for _, targetErr := range runtime.TargetErrors(dialErr) {
+		errs[targetErr.Target] = targetErr.Err
+	}
+	resps := make(map[string]*logs.SourcesResponse, len(msgs))
+	for target, msg := range msgs {
+		resps[target] = msg.(*logs.SourcesResponse)
+	}
+	return resps, errs
+}
+
+type runnerLogsFn func(context.Context, *proxyLogsClient, interface{}) (proto.Message, error)
+
+func proxyLogsRunner(ctx context.Context, clients []*proxyLogsClient, in interface{}, runner runnerLogsFn, execution runtime.Execution) ([]proto.Message, error) {
+	targets := make([]string, len(clients))
+	for i, client := range clients {
+		targets[i] = client.Target
+	}
+
+	return execution.Run(ctx, targets, func(ctx context.Context, i int) (proto.Message, error) {
+		return runner(ctx, clients[i], in)
+	})
+}
+
+type proxyLogsClient struct {
+	Conn     logs.LogsClient
+	Context  context.Context
+	Target   string
+	DialOpts []grpc.DialOption
+	CallOpts []grpc.CallOption
+}
+
+func proxySources(ctx context.Context, client *proxyLogsClient, in interface{}) (proto.Message, error) {
+	resp, err := client.Conn.Sources(ctx, in.(*logs.SourcesRequest), client.CallOpts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range resp.Response {
+		if msg.Metadata == nil {
+			msg.Metadata = &common.NodeMetadata{}
+		}
+		msg.Metadata.Hostname = client.Target
+	}
+	return resp, nil
+}
+
+type runnerTransferFn func(context.Context, *proxyTransferClient, interface{}) (proto.Message, error)
+
+func proxyTransferRunner(ctx context.Context, clients []*proxyTransferClient, in interface{}, runner runnerTransferFn, execution runtime.Execution) ([]proto.Message, error) {
+	targets := make([]string, len(clients))
+	for i, client := range clients {
+		targets[i] = client.Target
+	}
+
+	return execution.Run(ctx, targets, func(ctx context.Context, i int) (proto.Message, error) {
+		return runner(ctx, clients[i], in)
+	})
+}
+
+type proxyTransferClient struct {
+	Conn     transfer.TransferClient
+	Context  context.Context
+	Target   string
+	DialOpts []grpc.DialOption
+	CallOpts []grpc.CallOption
+}
+
+func (p *StreamingProxy) createLogsClient(ctx context.Context, targets []string, creds credentials.TransportCredentials, proxyMd metadata.MD, opts []grpc.CallOption) ([]*proxyLogsClient, func(), error) {
+	var errs []error
+	clients := make([]*proxyLogsClient, 0, len(targets))
+	releases := make([]func(), 0, len(targets))
+	for _, target := range targets {
+		c := &proxyLogsClient{
+			Context:  metadata.NewOutgoingContext(ctx, proxyMd),
+			Target:   target,
+			DialOpts: p.DialOptions,
+			CallOpts: opts,
+		}
+		dialTarget, err := runtime.DialTarget(target, p.DefaultPort)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		targetCreds, err := runtime.TargetCredentials(target, creds, p.CredentialsFor, p.VerifyTargetName)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		conn, release, err := p.Pool.Get(p.Dialer, dialTarget, targetCreds, c.DialOpts...)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		releases = append(releases, release)
+		c.Conn = logs.NewLogsClient(conn)
+		clients = append(clients, c)
+	}
+	return clients, func() {
+		for _, release := range releases {
+			release()
+		}
+	}, runtime.JoinErrors(errs...)
+}
+
+func (p *StreamingProxy) createTransferClient(ctx context.Context, targets []string, creds credentials.TransportCredentials, proxyMd metadata.MD, opts []grpc.CallOption) ([]*proxyTransferClient, func(), error) {
+	var errs []error
+	clients := make([]*proxyTransferClient, 0, len(targets))
+	releases := make([]func(), 0, len(targets))
+	for _, target := range targets {
+		c := &proxyTransferClient{
+			Context:  metadata.NewOutgoingContext(ctx, proxyMd),
+			Target:   target,
+			DialOpts: p.DialOptions,
+			CallOpts: opts,
+		}
+		dialTarget, err := runtime.DialTarget(target, p.DefaultPort)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		targetCreds, err := runtime.TargetCredentials(target, creds, p.CredentialsFor, p.VerifyTargetName)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		conn, release, err := p.Pool.Get(p.Dialer, dialTarget, targetCreds, c.DialOpts...)
+		if err != nil {
+			errs = append(errs, &runtime.TargetError{Target: target, Err: err})
+			continue
+		}
+		releases = append(releases, release)
+		c.Conn = transfer.NewTransferClient(conn)
+		clients = append(clients, c)
+	}
+	return clients, func() {
+		for _, release := range releases {
+			release()
+		}
+	}, runtime.JoinErrors(errs...)
+}
+
+type Registrator struct {
+	logs.LogsClient
+	logs.UnimplementedLogsServer
+	transfer.TransferClient
+	transfer.UnimplementedTransferServer
+}
+
+func (r *Registrator) Register(s *grpc.Server) {
+	logs.RegisterLogsServer(s, r)
+	transfer.RegisterTransferServer(s, r)
+
+}
+
+func (r *Registrator) Tail(in *logs.TailRequest, srv logs.Logs_TailServer) error {
+	client, err := r.LogsClient.Tail(srv.Context(), in)
+	if err != nil {
+		return err
+	}
+	return copyClientServer(func() interface{} { return new(logs.LogEntry) }, client, srv)
+}
+func (r *Registrator) Sources(ctx context.Context, in *logs.SourcesRequest) (*logs.SourcesResponse, error) {
+	return r.LogsClient.Sources(ctx, in)
+}
+func (r *Registrator) Upload(srv transfer.Transfer_UploadServer) error {
+	client, err := r.TransferClient.Upload(srv.Context())
+	if err != nil {
+		return err
+	}
+	if err = copyServerClient(func() interface{} { return new(transfer.Chunk) }, srv, client); err != nil {
+		return err
+	}
+	resp, err := client.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	return srv.SendAndClose(resp)
+}
+func (r *Registrator) Sync(srv transfer.Transfer_SyncServer) error {
+	ctx, cancel := context.WithCancel(srv.Context())
+	defer cancel()
+	client, err := r.TransferClient.Sync(ctx)
+	if err != nil {
+		return err
+	}
+	return copyBidiStream(func() interface{} { return new(transfer.Chunk) }, func() interface{} { return new(transfer.Ack) }, srv, client, cancel)
+}
+
+type LocalLogsClient struct {
+	logs.LogsClient
+}
+
+func NewLocalLogsClient() (logs.LogsClient, error) {
+	conn, err := grpc.Dial("unix:"+runtime.SocketPath("logs.Logs"),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalLogsClient{
+		LogsClient: logs.NewLogsClient(conn),
+	}, nil
+}
+
+func (c *LocalLogsClient) Tail(ctx context.Context, in *logs.TailRequest, opts ...grpc.CallOption) (logs.Logs_TailClient, error) {
+	return c.LogsClient.Tail(ctx, in, opts...)
+}
+func (c *LocalLogsClient) Sources(ctx context.Context, in *logs.SourcesRequest, opts ...grpc.CallOption) (*logs.SourcesResponse, error) {
+	return c.LogsClient.Sources(ctx, in, opts...)
+}
+
+type LocalTransferClient struct {
+	transfer.TransferClient
+}
+
+func NewLocalTransferClient() (transfer.TransferClient, error) {
+	conn, err := grpc.Dial("unix:"+runtime.SocketPath("transfer.Transfer"),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalTransferClient{
+		TransferClient: transfer.NewTransferClient(conn),
+	}, nil
+}
+
+func (c *LocalTransferClient) Upload(ctx context.Context, opts ...grpc.CallOption) (transfer.Transfer_UploadClient, error) {
+	return c.TransferClient.Upload(ctx, opts...)
+}
+func (c *LocalTransferClient) Sync(ctx context.Context, opts ...grpc.CallOption) (transfer.Transfer_SyncClient, error) {
+	return c.TransferClient.Sync(ctx, opts...)
+}
@@ -0,0 +1,224 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: transfer/transfer.proto
+
+package transfer
+
+import (
+	_ "github.com/talos-systems/protoc-gen-proxy/proxy"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Chunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	mi := &file_transfer_transfer_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_transfer_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_transfer_transfer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Chunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type UploadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          uint64                 `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadResponse) Reset() {
+	*x = UploadResponse{}
+	mi := &file_transfer_transfer_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadResponse) ProtoMessage() {}
+
+func (x *UploadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_transfer_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadResponse.ProtoReflect.Descriptor instead.
+func (*UploadResponse) Descriptor() ([]byte, []int) {
+	return file_transfer_transfer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *UploadResponse) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type Ack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offset        uint64                 `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ack) Reset() {
+	*x = Ack{}
+	mi := &file_transfer_transfer_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ack) ProtoMessage() {}
+
+func (x *Ack) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_transfer_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ack.ProtoReflect.Descriptor instead.
+func (*Ack) Descriptor() ([]byte, []int) {
+	return file_transfer_transfer_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Ack) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+var File_transfer_transfer_proto protoreflect.FileDescriptor
+
+const file_transfer_transfer_proto_rawDesc = "" +
+	"\n" +
+	"\x17transfer/transfer.proto\x12\btransfer\x1a\x13proxy/options.proto\"\x1b\n" +
+	"\x05Chunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"$\n" +
+	"\x0eUploadResponse\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x04R\x04size\"\x1d\n" +
+	"\x03Ack\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x04R\x06offset2\x80\x01\n" +
+	"\bTransfer\x125\n" +
+	"\x06Upload\x12\x0f.transfer.Chunk\x1a\x18.transfer.UploadResponse(\x01\x12*\n" +
+	"\x04Sync\x12\x0f.transfer.Chunk\x1a\r.transfer.Ack(\x010\x01\x1a\x11\x82\x80\x19\r*\v\x10\x80\x80\x80\x10\"\x04gzipBKZIgithub.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/transferb\x06proto3"
+
+var (
+	file_transfer_transfer_proto_rawDescOnce sync.Once
+	file_transfer_transfer_proto_rawDescData []byte
+)
+
+func file_transfer_transfer_proto_rawDescGZIP() []byte {
+	file_transfer_transfer_proto_rawDescOnce.Do(func() {
+		file_transfer_transfer_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_transfer_transfer_proto_rawDesc), len(file_transfer_transfer_proto_rawDesc)))
+	})
+	return file_transfer_transfer_proto_rawDescData
+}
+
+var file_transfer_transfer_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_transfer_transfer_proto_goTypes = []any{
+	(*Chunk)(nil),          // 0: transfer.Chunk
+	(*UploadResponse)(nil), // 1: transfer.UploadResponse
+	(*Ack)(nil),            // 2: transfer.Ack
+}
+var file_transfer_transfer_proto_depIdxs = []int32{
+	0, // 0: transfer.Transfer.Upload:input_type -> transfer.Chunk
+	0, // 1: transfer.Transfer.Sync:input_type -> transfer.Chunk
+	1, // 2: transfer.Transfer.Upload:output_type -> transfer.UploadResponse
+	2, // 3: transfer.Transfer.Sync:output_type -> transfer.Ack
+	2, // [2:4] is the sub-list for method output_type
+	0, // [0:2] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_transfer_transfer_proto_init() }
+func file_transfer_transfer_proto_init() {
+	if File_transfer_transfer_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_transfer_transfer_proto_rawDesc), len(file_transfer_transfer_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_transfer_transfer_proto_goTypes,
+		DependencyIndexes: file_transfer_transfer_proto_depIdxs,
+		MessageInfos:      file_transfer_transfer_proto_msgTypes,
+	}.Build()
+	File_transfer_transfer_proto = out.File
+	file_transfer_transfer_proto_goTypes = nil
+	file_transfer_transfer_proto_depIdxs = nil
+}
@@ -0,0 +1,60 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: unary.proto
+
+package unary
+
+import (
+	_ "github.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/node"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var File_unary_proto protoreflect.FileDescriptor
+
+const file_unary_proto_rawDesc = "" +
+	"\n" +
+	"\vunary.proto\x12\x05unary\x1a\x0fnode/node.protoBHZFgithub.com/talos-systems/protoc-gen-proxy/pkg/proxy/testdata/gen/unaryb\x06proto3"
+
+var file_unary_proto_goTypes = []any{}
+var file_unary_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_unary_proto_init() }
+func file_unary_proto_init() {
+	if File_unary_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_unary_proto_rawDesc), len(file_unary_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_unary_proto_goTypes,
+		DependencyIndexes: file_unary_proto_depIdxs,
+	}.Build()
+	File_unary_proto = out.File
+	file_unary_proto_goTypes = nil
+	file_unary_proto_depIdxs = nil
+}
@@ -5,105 +5,213 @@
 package proxy
 
 import (
-	"fmt"
+	"bytes"
 	"strings"
 
-	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
-	"github.com/golang/protobuf/protoc-gen-go/generator"
+	"google.golang.org/protobuf/compiler/protogen"
+
+	options "github.com/talos-systems/protoc-gen-proxy/proxy"
 )
 
 // generateUnaryProxyRouter creates the routing part of the proxy. That is it
 // enables us to map the incoming grpc method to the function/client call
 // so we can properly call the proper rpc endpoint.
-func (g *proxy) generateUnaryProxyRouter(serviceName string) {
-	// Leaving this in as left overs from grpc plugin, but not
-	// sure it really makes sense to keep it like this versus
-	// just calling the addimport call in Generate()
-	contextPkg = string(g.gen.AddImport(contextPkgPath))
-	grpcPkg = string(g.gen.AddImport(grpcPkgPath))
-
-	var args strings.Builder
-	args.WriteString("(")
-	args.WriteString("ctx " + contextPkg + ".Context, ")
-	args.WriteString("method string, ")
-	args.WriteString("creds credentials.TransportCredentials, ")
-	args.WriteString("in interface{}, ")
-	args.WriteString("opts ..." + grpcPkg + ".CallOption")
-	args.WriteString(")")
-
-	var returns strings.Builder
-	returns.WriteString("(")
-	returns.WriteString("proto.Message, ")
-	returns.WriteString("error")
-	returns.WriteString(")")
-
-	g.gen.P("func (p *" + generator.CamelCase(serviceName+"_proxy") + ") UnaryProxy " + args.String() + returns.String() + "{")
+func (g *proxy) generateUnaryProxyRouter() {
+	g.gen.P("func (p *"+g.proxyName()+") UnaryProxy(",
+		"ctx ", contextPackage.Ident("Context"), ", ",
+		"method string, ",
+		"creds ", credentialsPackage.Ident("TransportCredentials"), ", ",
+		"in interface{}, ",
+		"opts ...", grpcPackage.Ident("CallOption"),
+		") (",
+		protoPackage.Ident("Message"), ", ",
+		"error",
+		") {")
+
+	// Nothing to route
+	if g.ProxySwitch.Len() == 0 {
+		g.gen.P("return nil, ", runtimePackage.Ident("ErrUnknownMethod"))
+		g.gen.P("}")
+		g.gen.P("")
+
+		return
+	}
+
 	g.gen.P("var (")
 	g.gen.P("err error")
-	g.gen.P("errors *go_multierror.Error")
-	g.gen.P("msgs []proto.Message")
-	g.gen.P("ok bool")
-	g.gen.P("response proto.Message")
+	g.gen.P("msgs []", protoPackage.Ident("Message"))
+	g.gen.P("response ", protoPackage.Ident("Message"))
 	g.gen.P("targets []string")
 	g.gen.P(")")
 
 	// Parse targets from incoming metadata/context
-	g.gen.P("md, _ := metadata.FromIncomingContext(ctx)")
-	g.gen.P("// default to target node specified in config or on cli")
-	g.gen.P("if targets, ok = md[\"targets\"]; !ok {")
-	g.gen.P("targets = md[\":authority\"]")
+	g.gen.P("md, _ := ", metadataPackage.Ident("FromIncomingContext"), "(ctx)")
+	g.gen.P("// the requests without targets are meant for the proxy node itself, they")
+	g.gen.P("// are rejected rather than proxied to its own authority")
+	g.gen.P("targets = md[\"targets\"]")
+	g.gen.P("if p.Resolver != nil {")
+	g.gen.P("if targets, err = p.Resolver.Resolve(ctx, targets); err != nil {")
+	g.gen.P("return nil, err")
+	g.gen.P("}")
+	g.gen.P("}")
+	g.gen.P("// the targets are checked before any of them gets dialed")
+	g.gen.P("if err = p.TargetPolicy.Check(ctx, method, targets); err != nil {")
+	g.gen.P("return nil, err")
+	g.gen.P("}")
+	g.gen.P("if targets, err = ", runtimePackage.Ident("Authorize"), "(ctx, p.Authorizer, method, methodRoles(method), targets, in); err != nil {")
+	g.gen.P("return nil, err")
+	g.gen.P("}")
+	g.gen.P("if len(targets) == 0 {")
+	g.gen.P("return nil, ", statusPackage.Ident("Error"), "(", codesPackage.Ident("InvalidArgument"), ", \"no targets to proxy to\")")
 	g.gen.P("}")
 
 	// Set up client connections
-	g.gen.P("proxyMd := metadata.New(make(map[string]string))")
-	g.gen.P("proxyMd.Set(\"proxyfrom\", md[\":authority\"]...)")
+	g.gen.P("proxyMd := ", runtimePackage.Ident("ForwardMetadata"), "(md, p.ForwardedMetadata)")
+	g.gen.P(runtimePackage.Ident("SetVia"), "(proxyMd, md, p.Name)")
 	g.gen.P("")
 
 	// Handle routes
 	g.gen.P("switch method {")
 	g.gen.P(g.ProxySwitch.String())
+	g.gen.P("default:")
+	g.gen.P("return nil, ", runtimePackage.Ident("ErrUnknownMethod"))
 	g.gen.P("}")
 	g.gen.P("")
-	g.gen.P("if err != nil {")
-	g.gen.P("errors = go_multierror.Append(errors, err)")
-	g.gen.P("}")
-	g.gen.P("return response, errors.ErrorOrNil()")
+	g.gen.P("return response, err")
 	g.gen.P("}")
 }
 
-func (g *proxy) generateUnarySwitchStatement(serviceName, pkgName string, methods []*pb.MethodDescriptorProto) {
-	for _, method := range methods {
-		if method.GetServerStreaming() || method.GetClientStreaming() {
+func (g *proxy) generateUnarySwitchStatement(service *protogen.Service) {
+	for _, method := range service.Methods {
+		if method.Desc.IsStreamingServer() || method.Desc.IsStreamingClient() {
 			continue
 		}
-		// skip support for deprecated methods
-		if method.GetOptions().GetDeprecated() {
+
+		mode := methodMode(service, method)
+
+		// the interceptors check the roles of every method, whether it gets
+		// proxied or handled locally
+		g.collectRoles(service, method)
+
+		switch mode {
+		case options.Mode_FANOUT, options.Mode_SINGLE:
+		case options.Mode_LOCAL_ONLY:
+			g.localMethods = append(g.localMethods, fullMethodName(service, method))
+
+			continue
+		default:
 			continue
 		}
-		fullServName := serviceName
-		if pkgName != "" {
-			fullServName = pkgName + "." + fullServName
+
+		g.P(g.ProxySwitch, "case \""+fullMethodName(service, method)+"\":")
+		g.routedMethods = append(g.routedMethods, fullMethodName(service, method))
+
+		if mode == options.Mode_SINGLE {
+			g.P(g.ProxySwitch, "// Only the first target gets the request")
+			g.P(g.ProxySwitch, "if len(targets) > 1 {")
+			g.P(g.ProxySwitch, "targets = targets[:1]")
+			g.P(g.ProxySwitch, "}")
 		}
-		sname := fmt.Sprintf("/%s/%s", fullServName, method.GetName())
-		g.P(g.ProxySwitch, "case \""+sname+"\":")
+
+		if mode == options.Mode_FANOUT {
+			g.P(g.ProxySwitch, "execution := "+g.execution(service, method))
+			g.P(g.ProxySwitch, "if err = execution.ApplyMetadata(md); err != nil {")
+			g.P(g.ProxySwitch, "break")
+			g.P(g.ProxySwitch, "}")
+		}
+
+		g.generateCallOptions(g.ProxySwitch, service, method)
+
 		g.P(g.ProxySwitch, "// Initialize target clients")
-		g.P(g.ProxySwitch, "clients, err := create"+serviceName+"Client(targets, creds, proxyMd)")
+		g.P(g.ProxySwitch, "clients, release, dialErr := p.create"+service.GoName+"Client(ctx, targets, creds, proxyMd, opts)")
+		g.P(g.ProxySwitch, "defer release()")
+
+		agg := g.aggregations[method]
+
+		if mode == options.Mode_FANOUT && agg.inlineErrors {
+			g.generateInlineErrorsCase(service, method, agg)
+
+			continue
+		}
+
+		if mode == options.Mode_SINGLE {
+			g.P(g.ProxySwitch, "if dialErr != nil {")
+			g.P(g.ProxySwitch, "err = dialErr")
+			g.P(g.ProxySwitch, "break")
+			g.P(g.ProxySwitch, "}")
+			g.P(g.ProxySwitch, "response, err = clients[0].Conn."+method.GoName+"(clients[0].Context, in.(*"+g.typeName(method.Input)+"), clients[0].CallOpts...)")
+
+			continue
+		}
+
+		field := agg.list.GoName
+
+		g.P(g.ProxySwitch, "resp := &"+g.typeName(method.Output)+"{}")
+		g.generateRunnerCall(service, method)
+		g.P(g.ProxySwitch, "for _, msg := range msgs {")
+		g.P(g.ProxySwitch, "resp."+field+" = append(resp."+field+", msg.(*"+g.typeName(method.Output)+")."+field+"...)")
+		g.P(g.ProxySwitch, "}")
+		g.P(g.ProxySwitch, "response = resp")
+		g.P(g.ProxySwitch, "// the targets which couldn't be dialed failed as well")
+		g.P(g.ProxySwitch, "err = ", runtimePackage.Ident("JoinErrors"), "(dialErr, err)")
+	}
+}
+
+// generateInlineErrorsCase aggregates the responses of the targets along with
+// the errors of the failed ones, including the ones which couldn't be dialed
+// or got skipped, so a partial success comes back as a normal response.
+func (g *proxy) generateInlineErrorsCase(service *protogen.Service, method *protogen.Method, agg *aggregation) {
+	field := agg.list.GoName
+
+	g.P(g.ProxySwitch, "resp := &"+g.typeName(method.Output)+"{}")
+	g.generateRunnerCall(service, method)
+
+	// only the errors of the targets get inlined, a dispatch which isn't
+	// satisfied, e.g. a quorum out of reach, fails the whole call
+	if methodOptions(service, method).GetExecution().GetDispatch() != options.Dispatch_ALL {
 		g.P(g.ProxySwitch, "if err != nil {")
+		g.P(g.ProxySwitch, "err = ", runtimePackage.Ident("JoinErrors"), "(dialErr, err)")
 		g.P(g.ProxySwitch, "break")
 		g.P(g.ProxySwitch, "}")
+	}
 
-		var fnArgs strings.Builder
-		fnArgs.WriteString("(")
-		fnArgs.WriteString("clients, ")
-		fnArgs.WriteString("in, ")
-		fnArgs.WriteString("proxy" + generator.CamelCase(method.GetName()))
-		fnArgs.WriteString(")")
+	g.P(g.ProxySwitch, "for _, msg := range msgs {")
+	g.P(g.ProxySwitch, "resp."+field+" = append(resp."+field+", msg.(*"+g.typeName(method.Output)+")."+field+"...)")
+	g.P(g.ProxySwitch, "}")
+	g.P(g.ProxySwitch, "for _, targetErr := range ", runtimePackage.Ident("TargetErrors"), "(", runtimePackage.Ident("JoinErrors"), "(dialErr, err)) {")
+	g.P(g.ProxySwitch, "resp."+field+" = append(resp."+field+", "+g.errorEntry(agg, "targetErr.Target", "targetErr.Err")+")")
+	g.P(g.ProxySwitch, "}")
+	g.P(g.ProxySwitch, "response, err = resp, nil")
+}
 
-		g.P(g.ProxySwitch, "resp := &"+g.typeName(method.GetOutputType())+"{}")
-		g.P(g.ProxySwitch, "msgs, err = proxy"+serviceName+"Runner"+fnArgs.String())
-		g.P(g.ProxySwitch, "for _, msg := range msgs {")
-		g.P(g.ProxySwitch, "resp.Response = append(resp.Response, msg.(*"+g.typeName(method.GetOutputType())+").Response[0])")
+// generateRunnerCall calls the runner of the service. When the dispatch
+// doesn't call every target, the targets which couldn't be dialed count
+// against it, e.g. as failed votes of the quorum, and only get reported if it
+// failed.
+func (g *proxy) generateRunnerCall(service *protogen.Service, method *protogen.Method) {
+	dispatch := methodOptions(service, method).GetExecution().GetDispatch()
+
+	if dispatch != options.Dispatch_ALL {
+		g.P(g.ProxySwitch, "execution.Unreachable = len(", runtimePackage.Ident("TargetErrors"), "(dialErr))")
+	}
+
+	g.P(g.ProxySwitch, "msgs, err = proxy"+service.GoName+"Runner(", metadataPackage.Ident("NewOutgoingContext"), "(ctx, proxyMd), clients, in, proxy"+method.GoName+", execution)")
+
+	if dispatch != options.Dispatch_ALL {
+		g.P(g.ProxySwitch, "if err == nil {")
+		g.P(g.ProxySwitch, "dialErr = nil")
 		g.P(g.ProxySwitch, "}")
-		g.P(g.ProxySwitch, "response = resp")
 	}
 }
+
+// generateCallOptions appends the call options of the method to the ones of
+// the call, letting the method override them. The slice of the caller is left
+// alone.
+func (g *proxy) generateCallOptions(buf *bytes.Buffer, service *protogen.Service, method *protogen.Method) {
+	callOpts := g.callOptions(service, method)
+	if len(callOpts) == 0 {
+		return
+	}
+
+	g.P(buf, "opts = append(opts[:len(opts):len(opts)], "+strings.Join(callOpts, ", ")+")")
+}
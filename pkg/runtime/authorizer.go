@@ -0,0 +1,136 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"context"
+	"crypto/x509"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Identity is the identity of the caller, taken from the certificate it
+// presented over TLS.
+type Identity struct {
+	// Certificate is the leaf certificate of the caller.
+	Certificate *x509.Certificate
+	// Name is the common name of the certificate.
+	Name string
+	// Roles are the organizations of the certificate.
+	Roles []string
+}
+
+// HasRole reports whether the identity holds one of the roles.
+func (i *Identity) HasRole(roles ...string) bool {
+	if i == nil {
+		return false
+	}
+
+	for _, role := range roles {
+		if slices.Contains(i.Roles, role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PeerIdentity returns the identity of the caller of the request, it is nil
+// when the caller didn't present a certificate over TLS.
+func PeerIdentity(ctx context.Context) *Identity {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.PeerCertificates) == 0 {
+		return nil
+	}
+
+	cert := info.State.PeerCertificates[0]
+
+	return &Identity{
+		Certificate: cert,
+		Name:        cert.Subject.CommonName,
+		Roles:       cert.Subject.Organization,
+	}
+}
+
+// AuthorizationRequest describes a request about to be proxied.
+type AuthorizationRequest struct {
+	// FullMethod is the method of the request, e.g. /machine.Machine/Reboot.
+	FullMethod string
+	// Identity is the identity of the caller, nil if it is unknown.
+	Identity *Identity
+	// Roles lists the roles of the method, one of them being held by the
+	// caller.
+	Roles []string
+	// Targets are the targets of the request.
+	Targets []string
+	// Request is the request message of the unary and server streaming
+	// methods, the messages of the client streams aren't received yet.
+	Request interface{}
+}
+
+// Authorizer decides whether a request gets proxied. It returns the targets
+// to proxy the request to, which may only be a subset of the requested ones,
+// or an error rejecting the request.
+type Authorizer interface {
+	Authorize(ctx context.Context, req *AuthorizationRequest) ([]string, error)
+}
+
+// CheckRoles fails with PermissionDenied unless the caller holds one of the
+// roles of the method, any caller is allowed when there is no role.
+func CheckRoles(ctx context.Context, method string, roles []string) error {
+	if len(roles) > 0 && !PeerIdentity(ctx).HasRole(roles...) {
+		return status.Errorf(codes.PermissionDenied, "%s requires one of the roles %s", method, strings.Join(roles, ", "))
+	}
+
+	return nil
+}
+
+// Authorize checks that the caller holds one of the roles of the method, then
+// hands the request over to the authorizer, if any. It returns the targets
+// left to proxy the request to.
+func Authorize(ctx context.Context, authorizer Authorizer, method string, roles, targets []string, in interface{}) ([]string, error) {
+	if err := CheckRoles(ctx, method, roles); err != nil {
+		return nil, err
+	}
+
+	identity := PeerIdentity(ctx)
+
+	if authorizer == nil {
+		return targets, nil
+	}
+
+	allowed, err := authorizer.Authorize(ctx, &AuthorizationRequest{
+		FullMethod: method,
+		Identity:   identity,
+		Roles:      roles,
+		Targets:    slices.Clone(targets),
+		Request:    in,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, target := range allowed {
+		if !slices.Contains(targets, target) {
+			return nil, status.Errorf(codes.Internal, "authorizer added the target %q", target)
+		}
+	}
+
+	if len(allowed) == 0 && len(targets) > 0 {
+		return nil, status.Errorf(codes.PermissionDenied, "none of the targets of %s is allowed", method)
+	}
+
+	return allowed, nil
+}
@@ -0,0 +1,100 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+)
+
+type authorizerFunc func(ctx context.Context, req *runtime.AuthorizationRequest) ([]string, error)
+
+func (f authorizerFunc) Authorize(ctx context.Context, req *runtime.AuthorizationRequest) ([]string, error) {
+	return f(ctx, req)
+}
+
+func peerContext(name string, roles ...string) context.Context {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: name, Organization: roles}}
+
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}},
+	})
+}
+
+func TestAuthorizeRoles(t *testing.T) {
+	roles := []string{"os:admin", "os:operator"}
+
+	if _, err := runtime.Authorize(peerContext("bob", "os:reader"), nil, "/machine.Machine/Reboot", roles, targets, nil); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected a permission denied error, got %v", err)
+	}
+
+	// no identity without TLS
+	if _, err := runtime.Authorize(context.Background(), nil, "/machine.Machine/Reboot", roles, targets, nil); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected a permission denied error, got %v", err)
+	}
+
+	allowed, err := runtime.Authorize(peerContext("alice", "os:operator"), nil, "/machine.Machine/Reboot", roles, targets, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(allowed, targets) {
+		t.Errorf("unexpected targets %v", allowed)
+	}
+}
+
+func TestCheckRoles(t *testing.T) {
+	if err := runtime.CheckRoles(peerContext("bob", "os:reader"), "/machine.Machine/Reboot", []string{"os:admin"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected a permission denied error, got %v", err)
+	}
+
+	// any caller is allowed without roles
+	if err := runtime.CheckRoles(context.Background(), "/machine.Machine/Version", nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAuthorizeNarrow(t *testing.T) {
+	authorizer := authorizerFunc(func(_ context.Context, req *runtime.AuthorizationRequest) ([]string, error) {
+		if req.Identity.Name != "alice" || req.FullMethod != "/machine.Machine/Reboot" || req.Request != "in" {
+			return nil, status.Error(codes.PermissionDenied, "unexpected request")
+		}
+
+		// alice only reboots the first nodes
+		return req.Targets[:2], nil
+	})
+
+	allowed, err := runtime.Authorize(peerContext("alice"), authorizer, "/machine.Machine/Reboot", nil, targets, "in")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(allowed, targets[:2]) {
+		t.Errorf("unexpected targets %v", allowed)
+	}
+
+	if _, err = runtime.Authorize(peerContext("bob"), authorizer, "/machine.Machine/Reboot", nil, targets, "in"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected a permission denied error, got %v", err)
+	}
+
+	widening := authorizerFunc(func(_ context.Context, req *runtime.AuthorizationRequest) ([]string, error) {
+		return append(req.Targets, "10.6.0.2"), nil
+	})
+
+	if _, err = runtime.Authorize(peerContext("alice"), widening, "/machine.Machine/Reboot", nil, targets, "in"); status.Code(err) != codes.Internal {
+		t.Errorf("expected an internal error, got %v", err)
+	}
+}
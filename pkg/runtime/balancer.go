@@ -0,0 +1,102 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"sort"
+	"sync"
+)
+
+// Balancing selects the target of the requests dispatched to one of their
+// targets.
+type Balancing int
+
+// Balancing policies.
+const (
+	// RoundRobin rotates over the targets from one request to the next.
+	RoundRobin Balancing = iota
+	// LeastLoaded picks the target with the fewest calls in flight, the ties
+	// being broken by round-robin.
+	LeastLoaded
+)
+
+// Balancer keeps track of the calls made to the targets, it is shared by the
+// requests so their load gets spread over the targets.
+//
+// A nil Balancer tries the targets in the order of the request.
+type Balancer struct {
+	mu       sync.Mutex
+	next     uint64
+	inflight map[string]int
+}
+
+// NewBalancer creates a balancer.
+func NewBalancer() *Balancer {
+	return &Balancer{
+		inflight: make(map[string]int),
+	}
+}
+
+// Order returns the indexes of the targets in the order they should be tried.
+func (b *Balancer) Order(targets []string, balancing Balancing) []int {
+	order := make([]int, len(targets))
+	for i := range order {
+		order[i] = i
+	}
+
+	if b == nil || len(targets) == 0 {
+		return order
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	offset := int(b.next % uint64(len(targets)))
+	b.next++
+
+	for i := range order {
+		order[i] = (offset + i) % len(targets)
+	}
+
+	if balancing == LeastLoaded {
+		sort.SliceStable(order, func(i, j int) bool {
+			return b.inflight[targets[order[i]]] < b.inflight[targets[order[j]]]
+		})
+	}
+
+	return order
+}
+
+// Load returns the number of calls in flight to the target.
+func (b *Balancer) Load(target string) int {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.inflight[target]
+}
+
+// acquire records a call to the target, the returned func records its end.
+func (b *Balancer) acquire(target string) func() {
+	if b == nil {
+		return func() {}
+	}
+
+	b.mu.Lock()
+	b.inflight[target]++
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if b.inflight[target]--; b.inflight[target] <= 0 {
+			delete(b.inflight, target)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// CertificateProvider provides the CA and the client certificate used by the
+// proxy to authenticate against the targets.
+type CertificateProvider interface {
+	// GetCA returns the PEM encoded CA certificate(s) the targets are
+	// verified against.
+	GetCA() ([]byte, error)
+	// GetCertificate returns the client certificate of the proxy.
+	GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error)
+}
+
+// CertificateWatcher is implemented by the providers signalling the rotation
+// of their certificates through a channel.
+type CertificateWatcher interface {
+	// Rotated returns a channel closed once the certificates get rotated.
+	Rotated() <-chan struct{}
+}
+
+// CertificateVersioner is implemented by the providers signalling the
+// rotation of their certificates through a version.
+type CertificateVersioner interface {
+	// Version returns a number changing each time the certificates get
+	// rotated.
+	Version() uint64
+}
+
+// ClientTLSConfig builds the mutual TLS configuration used to dial the
+// targets. The client certificate is requested from the provider on each
+// handshake so a renewed certificate is picked up by the new connections.
+func ClientTLSConfig(provider CertificateProvider) (*tls.Config, error) {
+	ca, err := provider.GetCA()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CA: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("failed to parse CA certificate")
+	}
+
+	// fail early rather than on the first handshake
+	if _, err = provider.GetCertificate(nil); err != nil {
+		return nil, fmt.Errorf("failed to get certificate: %w", err)
+	}
+
+	return &tls.Config{
+		RootCAs: pool,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return provider.GetCertificate(nil)
+		},
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// ClientCredentials builds the transport credentials used to dial the targets.
+func ClientCredentials(provider CertificateProvider) (credentials.TransportCredentials, error) {
+	tlsConfig, err := ClientTLSConfig(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// CredentialsCache keeps the transport credentials of a provider across the
+// requests. They are rebuilt once the provider signals a rotation, as a
+// CertificateWatcher or a CertificateVersioner. The credentials of the other
+// providers are kept until the cache is dropped, only their client
+// certificate being renewed along the way.
+//
+// A nil CredentialsCache builds the credentials on each request.
+type CredentialsCache struct {
+	mu      sync.Mutex
+	creds   credentials.TransportCredentials
+	version uint64
+	rotated <-chan struct{}
+}
+
+// NewCredentialsCache creates a credentials cache.
+func NewCredentialsCache() *CredentialsCache {
+	return &CredentialsCache{}
+}
+
+// Get returns the credentials of the provider, building them on first use and
+// after a rotation. When the rebuild fails the previous credentials are kept
+// until the next call.
+func (c *CredentialsCache) Get(provider CertificateProvider) (credentials.TransportCredentials, error) {
+	if c == nil {
+		return ClientCredentials(provider)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.creds != nil && !c.stale(provider) {
+		return c.creds, nil
+	}
+
+	// picked before the build so a rotation happening meanwhile isn't missed
+	var (
+		version uint64
+		rotated <-chan struct{}
+	)
+
+	if versioner, ok := provider.(CertificateVersioner); ok {
+		version = versioner.Version()
+	}
+
+	if watcher, ok := provider.(CertificateWatcher); ok {
+		rotated = watcher.Rotated()
+	}
+
+	creds, err := ClientCredentials(provider)
+	if err != nil {
+		if c.creds != nil {
+			return c.creds, nil
+		}
+
+		return nil, err
+	}
+
+	c.creds, c.version, c.rotated = creds, version, rotated
+
+	return creds, nil
+}
+
+// stale reports whether the provider rotated its certificates since the
+// credentials got built.
+func (c *CredentialsCache) stale(provider CertificateProvider) bool {
+	if versioner, ok := provider.(CertificateVersioner); ok && versioner.Version() != c.version {
+		return true
+	}
+
+	if c.rotated != nil {
+		select {
+		case <-c.rotated:
+			return true
+		default:
+		}
+	}
+
+	return false
+}
+
+// CredentialsFunc returns the credentials to dial a target with, nil
+// credentials falling back to the default ones. The credentials should be
+// reused across the requests, the pooled connections being keyed by them.
+type CredentialsFunc func(target string) (credentials.TransportCredentials, error)
+
+// TargetCredentials returns the credentials to dial the target with: the ones
+// of credentialsFor, if any, or creds. With verifyName, the certificate of the
+// target must also be valid for the host of the target, see
+// VerifyTargetName.
+func TargetCredentials(target string, creds credentials.TransportCredentials, credentialsFor CredentialsFunc, verifyName bool) (credentials.TransportCredentials, error) {
+	if credentialsFor != nil {
+		targetCreds, err := credentialsFor(target)
+		if err != nil {
+			return nil, err
+		}
+
+		if targetCreds != nil {
+			creds = targetCreds
+		}
+	}
+
+	if verifyName {
+		return VerifyTargetName(creds, target)
+	}
+
+	return creds, nil
+}
+
+// VerifyTargetName wraps the credentials so the handshake fails unless the
+// certificate presented by the target is valid for the host of the target.
+// This holds even when the credentials verify another server name, so a
+// misrouted address can't answer for another node. The unix socket targets
+// are left alone.
+//
+// The wrapped credentials of the same target compare equal.
+func VerifyTargetName(creds credentials.TransportCredentials, target string) (credentials.TransportCredentials, error) {
+	host, err := TargetHost(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheme := targetScheme(target); scheme == "unix" || scheme == "unix-abstract" {
+		return creds, nil
+	}
+
+	return targetNameCredentials{TransportCredentials: creds, host: host}, nil
+}
+
+type targetNameCredentials struct {
+	credentials.TransportCredentials
+
+	host string
+}
+
+func (c targetNameCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, info, err := c.TransportCredentials.ClientHandshake(ctx, authority, rawConn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsInfo, ok := info.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		conn.Close() //nolint:errcheck
+
+		return nil, nil, fmt.Errorf("target %s didn't present a certificate", c.host)
+	}
+
+	if err = tlsInfo.State.PeerCertificates[0].VerifyHostname(c.host); err != nil {
+		conn.Close() //nolint:errcheck
+
+		return nil, nil, fmt.Errorf("certificate doesn't belong to the target: %w", err)
+	}
+
+	return conn, info, nil
+}
+
+func (c targetNameCredentials) Clone() credentials.TransportCredentials {
+	return targetNameCredentials{TransportCredentials: c.TransportCredentials.Clone(), host: c.host}
+}
@@ -0,0 +1,281 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+)
+
+// testProvider provides a self-signed certificate, acting as its own CA.
+type testProvider struct {
+	ca   []byte
+	cert tls.Certificate
+
+	calls atomic.Int32
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "proxy"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		DNSNames:              []string{"localhost"},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testProvider{
+		ca:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		cert: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+	}
+}
+
+func (p *testProvider) GetCA() ([]byte, error) {
+	p.calls.Add(1)
+
+	return p.ca, nil
+}
+
+func (p *testProvider) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return &p.cert, nil
+}
+
+type versionedProvider struct {
+	*testProvider
+
+	version atomic.Uint64
+}
+
+func (p *versionedProvider) Version() uint64 {
+	return p.version.Load()
+}
+
+type watchedProvider struct {
+	*testProvider
+
+	mu      sync.Mutex
+	rotated chan struct{}
+}
+
+func (p *watchedProvider) Rotated() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.rotated
+}
+
+func (p *watchedProvider) rotate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	close(p.rotated)
+	p.rotated = make(chan struct{})
+}
+
+func TestCredentialsCache(t *testing.T) {
+	provider := newTestProvider(t)
+	cache := runtime.NewCredentialsCache()
+
+	creds1, err := cache.Get(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creds2, err := cache.Get(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds1 != creds2 || provider.calls.Load() != 1 {
+		t.Error("credentials not cached")
+	}
+
+	// a nil cache builds them each time
+	if creds, err := (*runtime.CredentialsCache)(nil).Get(provider); err != nil || creds == creds1 {
+		t.Errorf("unexpected credentials %v, %v", creds, err)
+	}
+}
+
+func TestCredentialsCacheVersion(t *testing.T) {
+	provider := &versionedProvider{testProvider: newTestProvider(t)}
+	cache := runtime.NewCredentialsCache()
+
+	creds1, err := cache.Get(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider.version.Add(1)
+
+	creds2, err := cache.Get(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds1 == creds2 {
+		t.Error("credentials not rebuilt after a rotation")
+	}
+
+	if creds3, _ := cache.Get(provider); creds3 != creds2 { //nolint:errcheck
+		t.Error("credentials rebuilt without a rotation")
+	}
+}
+
+func TestCredentialsCacheWatch(t *testing.T) {
+	provider := &watchedProvider{testProvider: newTestProvider(t), rotated: make(chan struct{})}
+	cache := runtime.NewCredentialsCache()
+
+	creds1, err := cache.Get(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider.rotate()
+
+	creds2, err := cache.Get(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if creds1 == creds2 {
+		t.Error("credentials not rebuilt after a rotation")
+	}
+
+	if creds3, _ := cache.Get(provider); creds3 != creds2 { //nolint:errcheck
+		t.Error("credentials rebuilt without a rotation")
+	}
+}
+
+// handshake runs a TLS handshake between the credentials and a server
+// presenting the certificate of the provider.
+func handshake(t *testing.T, provider *testProvider, creds credentials.TransportCredentials, authority string) error {
+	t.Helper()
+
+	clientConn, serverConn := net.Pipe()
+
+	defer clientConn.Close() //nolint:errcheck
+	defer serverConn.Close() //nolint:errcheck
+
+	go func() {
+		server := tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{provider.cert}, NextProtos: []string{"h2"}})
+
+		// drained so the client can close the connection
+		io.Copy(io.Discard, server) //nolint:errcheck
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, _, err := creds.ClientHandshake(ctx, authority, clientConn)
+
+	return err
+}
+
+func TestVerifyTargetName(t *testing.T) {
+	provider := newTestProvider(t)
+
+	creds, err := runtime.ClientCredentials(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified, err := runtime.TargetCredentials("localhost:50000", creds, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = handshake(t, provider, verified, "localhost"); err != nil {
+		t.Errorf("handshake with the target failed: %s", err)
+	}
+
+	// the certificate is valid for the authority, not for the target
+	misrouted, err := runtime.TargetCredentials("10.5.0.2:50000", creds, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = handshake(t, provider, misrouted, "localhost"); err == nil {
+		t.Error("handshake with a misrouted target succeeded")
+	}
+
+	// stable across the requests so the pooled connections get reused
+	if again, _ := runtime.TargetCredentials("10.5.0.2:50000", creds, nil, true); again != misrouted { //nolint:errcheck
+		t.Error("credentials of the same target differ")
+	}
+
+	// only the unix sockets are left alone, not the hostnames looking like them
+	unixhost, err := runtime.TargetCredentials("unixhost.example.com:50000", creds, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = handshake(t, provider, unixhost, "localhost"); err == nil {
+		t.Error("handshake with a misrouted unix* target succeeded")
+	}
+
+	if socket, _ := runtime.TargetCredentials("unix:/var/run/machine.sock", creds, nil, true); socket != creds { //nolint:errcheck
+		t.Error("credentials of the unix socket got wrapped")
+	}
+}
+
+func TestCredentialsFor(t *testing.T) {
+	defaultCreds, clusterCreds := credentials.NewTLS(&tls.Config{}), credentials.NewTLS(&tls.Config{})
+
+	credentialsFor := func(target string) (credentials.TransportCredentials, error) {
+		if strings.HasSuffix(target, ".cluster-b") {
+			return clusterCreds, nil
+		}
+
+		return nil, nil
+	}
+
+	for target, expected := range map[string]credentials.TransportCredentials{
+		"node-1.cluster-a": defaultCreds,
+		"node-1.cluster-b": clusterCreds,
+	} {
+		creds, err := runtime.TargetCredentials(target, defaultCreds, credentialsFor, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if creds != expected {
+			t.Errorf("%s: unexpected credentials", target)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Dialer creates the client connections to the targets.
+type Dialer interface {
+	Dial(target string, creds credentials.TransportCredentials, opts ...grpc.DialOption) (*grpc.ClientConn, error)
+}
+
+// DialerFunc adapts a function to the Dialer interface.
+type DialerFunc func(target string, creds credentials.TransportCredentials, opts ...grpc.DialOption) (*grpc.ClientConn, error)
+
+// Dial calls f.
+func (f DialerFunc) Dial(target string, creds credentials.TransportCredentials, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return f(target, creds, opts...)
+}
+
+// NetworkDialer dials the targets over the network, it is used when no
+// Dialer is set.
+var NetworkDialer Dialer = DialerFunc(func(target string, creds credentials.TransportCredentials, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return grpc.Dial(target, append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, opts...)...)
+})
@@ -0,0 +1,78 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"errors"
+)
+
+// TargetError is the error returned by a single target.
+type TargetError struct {
+	Target string
+	Err    error
+}
+
+func (e *TargetError) Error() string {
+	return e.Target + ": " + e.Err.Error()
+}
+
+func (e *TargetError) Unwrap() error {
+	return e.Err
+}
+
+// TargetErrors returns the errors of the targets wrapped by err, looking
+// through the joined errors as well as the ones exposing WrappedErrors, e.g.
+// go-multierror ones. The errors of the targets are also decoded from the
+// statuses built by JoinErrors, so the clients of the proxy can find out which
+// target failed with what.
+func TargetErrors(err error) []*TargetError {
+	if err == nil {
+		return nil
+	}
+
+	var wrapped []error
+
+	switch e := err.(type) { //nolint:errorlint
+	case *TargetError:
+		return []*TargetError{e}
+	case interface{ Unwrap() []error }:
+		wrapped = e.Unwrap()
+	case interface{ WrappedErrors() []error }:
+		wrapped = e.WrappedErrors()
+	default:
+		var targetErr *TargetError
+		if errors.As(err, &targetErr) {
+			return []*TargetError{targetErr}
+		}
+
+		return statusTargetErrors(err)
+	}
+
+	var targetErrs []*TargetError
+
+	for _, e := range wrapped {
+		targetErrs = append(targetErrs, TargetErrors(e)...)
+	}
+
+	return targetErrs
+}
+
+// ErrorForTargets reports err for each of the targets, for the failures which
+// aren't tied to a single target, e.g. a request rejected by the target
+// policy. Without any target, err is keyed by an empty target so it doesn't
+// get lost.
+func ErrorForTargets(targets []string, err error) map[string]error {
+	if len(targets) == 0 {
+		return map[string]error{"": err}
+	}
+
+	errs := make(map[string]error, len(targets))
+
+	for _, target := range targets {
+		errs[target] = err
+	}
+
+	return errs
+}
@@ -0,0 +1,63 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+)
+
+// multiError mimics go-multierror.
+type multiError []error
+
+func (m multiError) Error() string {
+	return fmt.Sprint([]error(m))
+}
+
+func (m multiError) WrappedErrors() []error {
+	return m
+}
+
+func TestTargetErrors(t *testing.T) {
+	err := multiError{
+		&runtime.TargetError{Target: "10.5.0.2", Err: errors.New("connection refused")},
+		errors.New("no target"),
+		errors.Join(
+			fmt.Errorf("wrapped: %w", &runtime.TargetError{Target: "10.5.0.3", Err: errors.New("timeout")}),
+			&runtime.TargetError{Target: "10.5.0.4", Err: errors.New("denied")},
+		),
+	}
+
+	var targets []string
+
+	for _, targetErr := range runtime.TargetErrors(err) {
+		targets = append(targets, targetErr.Target)
+	}
+
+	if fmt.Sprint(targets) != "[10.5.0.2 10.5.0.3 10.5.0.4]" {
+		t.Errorf("unexpected targets %v", targets)
+	}
+
+	if runtime.TargetErrors(nil) != nil {
+		t.Error("expected no target errors")
+	}
+}
+
+func TestErrorForTargets(t *testing.T) {
+	err := errors.New("denied")
+
+	errs := runtime.ErrorForTargets([]string{"10.5.0.2", "10.5.0.3"}, err)
+	if len(errs) != 2 || errs["10.5.0.2"] != err || errs["10.5.0.3"] != err {
+		t.Errorf("unexpected errors %v", errs)
+	}
+
+	// the error isn't lost without targets
+	if errs = runtime.ErrorForTargets(nil, err); len(errs) != 1 || errs[""] != err {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
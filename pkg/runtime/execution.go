@@ -0,0 +1,416 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Metadata keys overriding the execution of a request.
+const (
+	MetadataMaxConcurrency = "proxy-max-concurrency"
+	MetadataBatchSize      = "proxy-batch-size"
+	MetadataStopOnError    = "proxy-stop-on-error"
+	MetadataTargetTimeout  = "proxy-target-timeout"
+)
+
+// Dispatch selects the targets which answer a fanned out request.
+type Dispatch int
+
+// Dispatch modes.
+const (
+	// DispatchAll calls every target and returns all their responses.
+	DispatchAll Dispatch = iota
+	// DispatchAny calls the targets at once and returns the first success,
+	// the other calls get cancelled.
+	DispatchAny
+	// DispatchQuorum calls the targets at once and returns as soon as a
+	// majority of them succeeded, the other calls get cancelled.
+	DispatchQuorum
+	// DispatchOneOf calls a single target picked by the Balancer, moving on
+	// to the next one when it fails.
+	DispatchOneOf
+)
+
+// ErrSkipped is the error of the targets skipped after a failed wave.
+var ErrSkipped = status.Error(codes.Aborted, "skipped after a failure in a previous wave")
+
+// ErrTooFewTargets is returned when too many targets are unreachable for the
+// dispatch to be satisfied, e.g. for a majority of them to answer. None of the
+// targets gets called then.
+var ErrTooFewTargets = status.Error(codes.Unavailable, "too few reachable targets for the dispatch")
+
+// Execution controls how the calls of a fanned out request are spread over
+// the targets.
+type Execution struct {
+	// MaxConcurrency caps the number of targets called at once, zero doesn't
+	// cap them.
+	MaxConcurrency int
+	// BatchSize rolls the request out in waves of BatchSize targets, each
+	// wave starting once the previous one is done. Zero calls all the
+	// targets in a single wave.
+	BatchSize int
+	// StopOnError skips the waves left once a target fails.
+	StopOnError bool
+	// TargetTimeout bounds the call to each target, the targets which don't
+	// answer in time fail with DeadlineExceeded while the answers of the
+	// others are kept. Zero only applies the deadline of the request.
+	TargetTimeout time.Duration
+	// Dispatch selects the targets which answer the request, BatchSize and
+	// StopOnError only apply to DispatchAll.
+	Dispatch Dispatch
+	// Balancing picks the target of DispatchOneOf.
+	Balancing Balancing
+	// Balancer tracks the load of the targets across the requests.
+	Balancer *Balancer
+	// Unreachable is the number of targets of the request which couldn't be
+	// called at all, e.g. as they couldn't be dialed. They count as failed
+	// votes so the quorum stays a majority of all the targets.
+	Unreachable int
+}
+
+// ApplyMetadata tightens the execution with the proxy-max-concurrency,
+// proxy-batch-size, proxy-stop-on-error and proxy-target-timeout keys of the
+// incoming metadata. The timeout is a Go duration such as "5s".
+//
+// The callers can't lift the limits set by the execution options of the
+// method: the concurrency, batch size and timeout only apply when they are
+// lower than the current non-zero ones, or set the missing ones, and
+// StopOnError can be turned on but not off.
+func (e *Execution) ApplyMetadata(md metadata.MD) error {
+	for _, setting := range []struct {
+		key   string
+		value *int
+	}{
+		{key: MetadataMaxConcurrency, value: &e.MaxConcurrency},
+		{key: MetadataBatchSize, value: &e.BatchSize},
+	} {
+		values := md.Get(setting.key)
+		if len(values) == 0 {
+			continue
+		}
+
+		n, err := strconv.Atoi(values[0])
+		if err != nil || n < 0 {
+			return status.Errorf(codes.InvalidArgument, "invalid %s %q", setting.key, values[0])
+		}
+
+		if n > 0 && (*setting.value == 0 || n < *setting.value) {
+			*setting.value = n
+		}
+	}
+
+	if values := md.Get(MetadataStopOnError); len(values) > 0 {
+		stop, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid %s %q", MetadataStopOnError, values[0])
+		}
+
+		e.StopOnError = e.StopOnError || stop
+	}
+
+	if values := md.Get(MetadataTargetTimeout); len(values) > 0 {
+		timeout, err := time.ParseDuration(values[0])
+		if err != nil || timeout < 0 {
+			return status.Errorf(codes.InvalidArgument, "invalid %s %q", MetadataTargetTimeout, values[0])
+		}
+
+		if timeout > 0 && (e.TargetTimeout == 0 || timeout < e.TargetTimeout) {
+			e.TargetTimeout = timeout
+		}
+	}
+
+	return nil
+}
+
+// TargetContext derives the context of the call to a single target from ctx.
+// The call is bounded by the TargetTimeout, on top of any deadline ctx
+// already carries, and the returned func must be called once it is done.
+func (e Execution) TargetContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.TargetTimeout > 0 {
+		return context.WithTimeout(ctx, e.TargetTimeout)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// Run calls the targets as the dispatch commands, passing the index of the
+// target and the context of the call to call. It returns the responses kept by
+// the dispatch, in the order of the targets, along with the errors of the
+// targets aggregated by JoinErrors.
+func (e Execution) Run(ctx context.Context, targets []string, call func(ctx context.Context, i int) (proto.Message, error)) ([]proto.Message, error) {
+	if !e.reachable(len(targets)) {
+		return nil, ErrTooFewTargets
+	}
+
+	responses, errs := e.run(ctx, targets, call)
+
+	return collect(targets, responses, errs)
+}
+
+// RunTargets calls the targets like Run, it returns the responses of the
+// targets which succeeded and the errors of the ones which failed, keyed by
+// target. The targets the dispatch didn't need, whether they weren't called
+// or their call got cancelled, are left out of both. ErrTooFewTargets isn't
+// tied to a target, it is keyed by an empty target.
+func (e Execution) RunTargets(ctx context.Context, targets []string, call func(ctx context.Context, i int) (proto.Message, error)) (map[string]proto.Message, map[string]error) {
+	if !e.reachable(len(targets)) {
+		return map[string]proto.Message{}, map[string]error{"": ErrTooFewTargets}
+	}
+
+	responses, errs := e.run(ctx, targets, call)
+
+	resps := make(map[string]proto.Message, len(targets))
+	targetErrs := make(map[string]error)
+
+	for i, target := range targets {
+		switch {
+		case errs[i] != nil:
+			targetErrs[target] = errs[i]
+		case responses[i] != nil:
+			resps[target] = responses[i]
+		}
+	}
+
+	return resps, targetErrs
+}
+
+// needed is the number of targets which must succeed for the dispatch to be
+// satisfied, out of the targets called and the unreachable ones.
+func (e Execution) needed(called int) int {
+	total := called + e.Unreachable
+
+	switch {
+	case total == 0:
+		return 0
+	case e.Dispatch == DispatchAny, e.Dispatch == DispatchOneOf:
+		return 1
+	case e.Dispatch == DispatchQuorum:
+		return total/2 + 1
+	default:
+		return 0
+	}
+}
+
+// reachable reports whether the dispatch can still be satisfied by the
+// targets called.
+func (e Execution) reachable(called int) bool {
+	return e.needed(called) <= called
+}
+
+// run calls the targets as the dispatch commands, it returns the responses
+// and the errors kept by the dispatch, indexed like the targets.
+func (e Execution) run(ctx context.Context, targets []string, call func(ctx context.Context, i int) (proto.Message, error)) ([]proto.Message, []error) {
+	switch e.Dispatch {
+	case DispatchAny, DispatchQuorum:
+		return e.runUntil(ctx, targets, call, e.needed(len(targets)))
+	case DispatchOneOf:
+		return e.runOneOf(ctx, targets, call)
+	default:
+		return e.runAll(ctx, targets, call)
+	}
+}
+
+// runAll calls every target, wave after wave. The targets of the waves
+// skipped after a failure fail with ErrSkipped.
+func (e Execution) runAll(ctx context.Context, targets []string, call func(ctx context.Context, i int) (proto.Message, error)) ([]proto.Message, []error) {
+	batchSize := len(targets)
+	if e.BatchSize > 0 && e.BatchSize < batchSize {
+		batchSize = e.BatchSize
+	}
+
+	responses := make([]proto.Message, len(targets))
+	errs := make([]error, len(targets))
+
+	for start := 0; start < len(targets); start += batchSize {
+		end := min(start+batchSize, len(targets))
+
+		if !e.runWave(ctx, targets, start, end, call, responses, errs) && e.StopOnError {
+			for i := end; i < len(targets); i++ {
+				errs[i] = ErrSkipped
+			}
+
+			break
+		}
+	}
+
+	return responses, errs
+}
+
+// runWave calls the targets from start to end, it reports whether all of them
+// succeeded.
+func (e Execution) runWave(ctx context.Context, targets []string, start, end int, call func(ctx context.Context, i int) (proto.Message, error), responses []proto.Message, errs []error) bool {
+	var (
+		wg  sync.WaitGroup
+		sem chan struct{}
+	)
+
+	if e.MaxConcurrency > 0 {
+		sem = make(chan struct{}, e.MaxConcurrency)
+	}
+
+	for i := start; i < end; i++ {
+		if sem != nil {
+			sem <- struct{}{}
+		}
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			responses[i], errs[i] = e.call(ctx, targets, i, call)
+
+			if sem != nil {
+				<-sem
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs[start:end] {
+		if err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// runUntil calls the targets until needed of them succeeded, the calls still
+// in flight then get cancelled and their outcome is dropped. When too many
+// targets failed for needed of them to succeed, the responses received so far
+// are returned along with the errors.
+func (e Execution) runUntil(ctx context.Context, targets []string, call func(ctx context.Context, i int) (proto.Message, error), needed int) ([]proto.Message, []error) {
+	type result struct {
+		i    int
+		resp proto.Message
+		err  error
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// buffered so the cancelled calls don't block
+	results := make(chan result, len(targets))
+
+	concurrency := len(targets)
+	if e.MaxConcurrency > 0 && e.MaxConcurrency < concurrency {
+		concurrency = e.MaxConcurrency
+	}
+
+	var next, pending int
+
+	start := func() {
+		i := next
+		next++
+		pending++
+
+		go func() {
+			resp, err := e.call(ctx, targets, i, call)
+
+			results <- result{i: i, resp: resp, err: err}
+		}()
+	}
+
+	for next < concurrency {
+		start()
+	}
+
+	responses := make([]proto.Message, len(targets))
+	errs := make([]error, len(targets))
+
+	var succeeded, failed int
+
+	for pending > 0 && succeeded < needed && failed <= len(targets)-needed {
+		r := <-results
+		pending--
+
+		responses[r.i], errs[r.i] = r.resp, r.err
+
+		if r.err != nil {
+			failed++
+		} else {
+			succeeded++
+		}
+
+		if next < len(targets) {
+			start()
+		}
+	}
+
+	if succeeded >= needed {
+		errs = make([]error, len(targets))
+	}
+
+	return responses, errs
+}
+
+// runOneOf calls the targets one at a time, in the order of the balancer,
+// until one of them succeeds.
+func (e Execution) runOneOf(ctx context.Context, targets []string, call func(ctx context.Context, i int) (proto.Message, error)) ([]proto.Message, []error) {
+	responses := make([]proto.Message, len(targets))
+	errs := make([]error, len(targets))
+
+	for _, i := range e.Balancer.Order(targets, e.Balancing) {
+		resp, err := e.call(ctx, targets, i, call)
+		if err == nil {
+			responses[i] = resp
+
+			return responses, make([]error, len(targets))
+		}
+
+		errs[i] = err
+
+		// the request is gone, the targets left would fail as well
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return responses, errs
+}
+
+// call calls the target i with its own context, bounded by the TargetTimeout.
+func (e Execution) call(ctx context.Context, targets []string, i int, call func(ctx context.Context, i int) (proto.Message, error)) (proto.Message, error) {
+	ctx, cancel := e.TargetContext(ctx)
+	defer cancel()
+
+	defer e.Balancer.acquire(targets[i])()
+
+	return call(ctx, i)
+}
+
+// collect returns the responses of the succeeded targets and the errors of
+// the failed ones.
+func collect(targets []string, responses []proto.Message, errs []error) ([]proto.Message, error) {
+	var (
+		kept       []proto.Message
+		targetErrs []error
+	)
+
+	for i, err := range errs {
+		if err != nil {
+			targetErrs = append(targetErrs, &TargetError{Target: targets[i], Err: err})
+
+			continue
+		}
+
+		if responses[i] != nil {
+			kept = append(kept, responses[i])
+		}
+	}
+
+	return kept, JoinErrors(targetErrs...)
+}
@@ -0,0 +1,372 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+)
+
+var targets = []string{"10.5.0.2", "10.5.0.3", "10.5.0.4", "10.5.0.5", "10.5.0.6"}
+
+func TestExecutionMaxConcurrency(t *testing.T) {
+	var (
+		mu               sync.Mutex
+		running, maxSeen int
+	)
+
+	_, err := runtime.Execution{MaxConcurrency: 2}.Run(context.Background(), targets, func(context.Context, int) (proto.Message, error) {
+		mu.Lock()
+		running++
+		maxSeen = max(maxSeen, running)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		return &emptypb.Empty{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if maxSeen > 2 {
+		t.Errorf("%d targets called at once", maxSeen)
+	}
+}
+
+func TestExecutionRolling(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		called []int
+	)
+
+	_, err := runtime.Execution{BatchSize: 2, StopOnError: true}.Run(context.Background(), targets, func(_ context.Context, i int) (proto.Message, error) {
+		mu.Lock()
+		called = append(called, i)
+		mu.Unlock()
+
+		if i == 2 {
+			return nil, errors.New("upgrade failed")
+		}
+
+		return &emptypb.Empty{}, nil
+	})
+
+	// the second wave fails, the last target is skipped
+	if len(called) != 4 {
+		t.Errorf("unexpected calls %v", called)
+	}
+
+	failed := make(map[string]codes.Code)
+
+	for _, targetErr := range runtime.TargetErrors(err) {
+		failed[targetErr.Target] = status.Code(targetErr.Err)
+	}
+
+	if len(failed) != 2 || failed["10.5.0.4"] != codes.Unknown || failed["10.5.0.6"] != codes.Aborted {
+		t.Errorf("unexpected errors %v", err)
+	}
+}
+
+func TestExecutionApplyMetadata(t *testing.T) {
+	execution := runtime.Execution{MaxConcurrency: 4}
+
+	md := metadata.Pairs(runtime.MetadataBatchSize, "1", runtime.MetadataStopOnError, "true")
+	if err := execution.ApplyMetadata(md); err != nil {
+		t.Fatal(err)
+	}
+
+	if execution != (runtime.Execution{MaxConcurrency: 4, BatchSize: 1, StopOnError: true}) {
+		t.Errorf("unexpected execution %+v", execution)
+	}
+
+	md = metadata.Pairs(runtime.MetadataTargetTimeout, "1.5s")
+	if err := execution.ApplyMetadata(md); err != nil {
+		t.Fatal(err)
+	}
+
+	if execution.TargetTimeout != 1500*time.Millisecond {
+		t.Errorf("unexpected target timeout %s", execution.TargetTimeout)
+	}
+
+	// the limits can't be lifted
+	md = metadata.Pairs(
+		runtime.MetadataMaxConcurrency, "0",
+		runtime.MetadataBatchSize, "8",
+		runtime.MetadataStopOnError, "false",
+		runtime.MetadataTargetTimeout, "1m",
+	)
+	if err := execution.ApplyMetadata(md); err != nil {
+		t.Fatal(err)
+	}
+
+	if execution != (runtime.Execution{MaxConcurrency: 4, BatchSize: 1, StopOnError: true, TargetTimeout: 1500 * time.Millisecond}) {
+		t.Errorf("unexpected execution %+v", execution)
+	}
+
+	// but they can be tightened
+	if err := execution.ApplyMetadata(metadata.Pairs(runtime.MetadataMaxConcurrency, "2")); err != nil {
+		t.Fatal(err)
+	}
+
+	if execution.MaxConcurrency != 2 {
+		t.Errorf("unexpected max concurrency %d", execution.MaxConcurrency)
+	}
+
+	for _, md := range []metadata.MD{
+		metadata.Pairs(runtime.MetadataMaxConcurrency, "-1"),
+		metadata.Pairs(runtime.MetadataTargetTimeout, "5"),
+	} {
+		if err := execution.ApplyMetadata(md); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected an invalid argument error, got %v", err)
+		}
+	}
+}
+
+func TestExecutionTargetTimeout(t *testing.T) {
+	execution := runtime.Execution{TargetTimeout: 20 * time.Millisecond}
+
+	responses, err := execution.Run(context.Background(), targets, func(ctx context.Context, i int) (proto.Message, error) {
+		// the last target hangs
+		if i == len(targets)-1 {
+			<-ctx.Done()
+
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+
+		return wrapperspb.String(targets[i]), nil
+	})
+
+	targetErrs := runtime.TargetErrors(err)
+	if len(targetErrs) != 1 || targetErrs[0].Target != "10.5.0.6" || status.Code(targetErrs[0].Err) != codes.DeadlineExceeded {
+		t.Fatalf("unexpected errors %v", err)
+	}
+
+	if len(responses) != len(targets)-1 {
+		t.Errorf("unexpected responses %v", responses)
+	}
+}
+
+// answer makes the targets from the fourth one fail, the others answer after
+// a delay growing with their index unless cancelled.
+func answer(ctx context.Context, i int) (proto.Message, error) {
+	if i >= 3 {
+		return nil, status.Error(codes.Unavailable, "node is down")
+	}
+
+	select {
+	case <-time.After(time.Duration(i) * 20 * time.Millisecond):
+		return wrapperspb.String(targets[i]), nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func TestExecutionDispatchAny(t *testing.T) {
+	responses, err := runtime.Execution{Dispatch: runtime.DispatchAny}.Run(context.Background(), targets, answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(responses) != 1 || responses[0].(*wrapperspb.StringValue).GetValue() != "10.5.0.2" {
+		t.Errorf("unexpected responses %v", responses)
+	}
+}
+
+func TestExecutionDispatchQuorum(t *testing.T) {
+	responses, err := runtime.Execution{Dispatch: runtime.DispatchQuorum}.Run(context.Background(), targets, answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(responses) != 3 {
+		t.Errorf("unexpected responses %v", responses)
+	}
+
+	// no quorum out of the failing targets
+	responses, err = runtime.Execution{Dispatch: runtime.DispatchQuorum}.Run(context.Background(), targets[1:], func(ctx context.Context, i int) (proto.Message, error) {
+		return answer(ctx, i+1)
+	})
+	if len(responses) > 2 || len(runtime.TargetErrors(err)) != 2 {
+		t.Errorf("unexpected outcome %v, %v", responses, err)
+	}
+}
+
+func TestExecutionDispatchOneOf(t *testing.T) {
+	execution := runtime.Execution{Dispatch: runtime.DispatchOneOf, Balancer: runtime.NewBalancer()}
+
+	seen := make(map[string]int)
+
+	for range targets {
+		responses, err := execution.Run(context.Background(), targets, func(ctx context.Context, i int) (proto.Message, error) {
+			return wrapperspb.String(targets[i]), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(responses) != 1 {
+			t.Fatalf("unexpected responses %v", responses)
+		}
+
+		seen[responses[0].(*wrapperspb.StringValue).GetValue()]++
+	}
+
+	// round-robin over the targets
+	if len(seen) != len(targets) {
+		t.Errorf("unexpected targets %v", seen)
+	}
+
+	// the failed targets are skipped
+	responses, err := execution.Run(context.Background(), targets[3:], func(ctx context.Context, i int) (proto.Message, error) {
+		return answer(ctx, i+3)
+	})
+	if responses != nil || len(runtime.TargetErrors(err)) != 2 {
+		t.Errorf("unexpected outcome %v, %v", responses, err)
+	}
+}
+
+func TestBalancerLeastLoaded(t *testing.T) {
+	balancer := runtime.NewBalancer()
+	execution := runtime.Execution{Dispatch: runtime.DispatchOneOf, Balancing: runtime.LeastLoaded, Balancer: balancer}
+
+	// keep a call in flight to every target but the last one
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	for i := range targets[:len(targets)-1] {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			execution.Run(context.Background(), targets[i:i+1], func(context.Context, int) (proto.Message, error) { //nolint:errcheck
+				<-release
+
+				return &emptypb.Empty{}, nil
+			})
+		}(i)
+	}
+
+	for i := range targets[:len(targets)-1] {
+		for balancer.Load(targets[i]) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	responses, err := execution.Run(context.Background(), targets, func(_ context.Context, i int) (proto.Message, error) {
+		return wrapperspb.String(targets[i]), nil
+	})
+
+	close(release)
+	wg.Wait()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if responses[0].(*wrapperspb.StringValue).GetValue() != "10.5.0.6" {
+		t.Errorf("unexpected target %v", responses[0])
+	}
+}
+
+func TestExecutionTargetContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	targetCtx, targetCancel := runtime.Execution{TargetTimeout: time.Hour}.TargetContext(ctx)
+	defer targetCancel()
+
+	// the deadline of the request still applies
+	deadline, _ := targetCtx.Deadline()
+	if expected, _ := ctx.Deadline(); !deadline.Equal(expected) {
+		t.Errorf("unexpected deadline %s, expected %s", deadline, expected)
+	}
+}
+
+func TestExecutionRunTargets(t *testing.T) {
+	responses, errs := runtime.Execution{}.RunTargets(context.Background(), targets, func(ctx context.Context, i int) (proto.Message, error) {
+		if i >= 3 {
+			return nil, status.Error(codes.Unavailable, "node is down")
+		}
+
+		return wrapperspb.String(targets[i]), nil
+	})
+
+	if len(responses) != 3 || responses["10.5.0.3"].(*wrapperspb.StringValue).GetValue() != "10.5.0.3" {
+		t.Errorf("unexpected responses %v", responses)
+	}
+
+	if len(errs) != 2 || status.Code(errs["10.5.0.6"]) != codes.Unavailable {
+		t.Errorf("unexpected errors %v", errs)
+	}
+
+	// the targets the dispatch didn't need are left out
+	responses, errs = runtime.Execution{Dispatch: runtime.DispatchAny}.RunTargets(context.Background(), targets, answer)
+
+	if len(responses) != 1 || responses["10.5.0.2"] == nil || len(errs) != 0 {
+		t.Errorf("unexpected outcome %v, %v", responses, errs)
+	}
+}
+
+func TestExecutionUnreachable(t *testing.T) {
+	// 2 out of 5 targets isn't a majority
+	execution := runtime.Execution{Dispatch: runtime.DispatchQuorum, Unreachable: 3}
+
+	called := false
+
+	_, err := execution.Run(context.Background(), targets[:2], func(context.Context, int) (proto.Message, error) {
+		called = true
+
+		return &emptypb.Empty{}, nil
+	})
+	if !errors.Is(err, runtime.ErrTooFewTargets) || called {
+		t.Errorf("unexpected outcome %v, called: %v", err, called)
+	}
+
+	// 3 out of 5 are
+	execution.Unreachable = 2
+
+	responses, err := execution.Run(context.Background(), targets[:3], func(context.Context, int) (proto.Message, error) {
+		return &emptypb.Empty{}, nil
+	})
+	if err != nil || len(responses) != 3 {
+		t.Errorf("unexpected outcome %v, %v", responses, err)
+	}
+
+	// a failure among them loses the majority
+	_, err = execution.Run(context.Background(), targets[2:5], answer2)
+	if len(runtime.TargetErrors(err)) == 0 {
+		t.Errorf("expected the errors of the targets, got %v", err)
+	}
+
+	// no target to answer at all
+	_, errs := runtime.Execution{Dispatch: runtime.DispatchAny, Unreachable: 2}.RunTargets(context.Background(), nil, answer)
+	if !errors.Is(errs[""], runtime.ErrTooFewTargets) {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
+
+// answer2 answers like answer for the targets from the third one.
+func answer2(ctx context.Context, i int) (proto.Message, error) {
+	return answer(ctx, i+2)
+}
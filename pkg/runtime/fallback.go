@@ -0,0 +1,39 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrUnknownMethod is returned by the proxies for the methods of the proxied
+// services they don't route, e.g. the ones added after the proxy was
+// generated or the ones left out with the SKIP mode.
+var ErrUnknownMethod = status.Error(codes.Unimplemented, "method is not proxied")
+
+// LocalUnaryFallback hands the unknown unary methods over to the local
+// handler.
+func LocalUnaryFallback(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(ctx, req)
+}
+
+// LocalStreamFallback hands the unknown streaming methods over to the local
+// handler.
+func LocalStreamFallback(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, ss)
+}
+
+// ServiceName returns the service part of a full method name, e.g.
+// "pkg.Service" for "/pkg.Service/Method".
+func ServiceName(fullMethod string) string {
+	service, _, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+
+	return service
+}
@@ -0,0 +1,24 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+)
+
+func TestServiceName(t *testing.T) {
+	for fullMethod, expected := range map[string]string{
+		"/machine.MachineService/Version": "machine.MachineService",
+		"/grpc.health.v1.Health/Check":    "grpc.health.v1.Health",
+		"Service/Method":                  "Service",
+		"":                                "",
+	} {
+		if service := runtime.ServiceName(fullMethod); service != expected {
+			t.Errorf("%q: expected %q, got %q", fullMethod, expected, service)
+		}
+	}
+}
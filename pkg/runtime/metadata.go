@@ -0,0 +1,23 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"google.golang.org/grpc/metadata"
+)
+
+// ForwardMetadata picks the given keys of the incoming metadata to pass them
+// on to the targets.
+func ForwardMetadata(md metadata.MD, keys []string) metadata.MD {
+	forwarded := metadata.MD{}
+
+	for _, key := range keys {
+		if values := md.Get(key); len(values) > 0 {
+			forwarded.Set(key, values...)
+		}
+	}
+
+	return forwarded
+}
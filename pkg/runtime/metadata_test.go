@@ -0,0 +1,30 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime_test
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+)
+
+func TestForwardMetadata(t *testing.T) {
+	md := metadata.Pairs(
+		":authority", "10.5.0.2",
+		"targets", "10.5.0.3",
+		"x-request-id", "42",
+		"Authorization", "Bearer token",
+	)
+
+	forwarded := runtime.ForwardMetadata(md, []string{"X-Request-ID", "authorization", "x-missing"})
+
+	expected := metadata.Pairs("x-request-id", "42", "authorization", "Bearer token")
+	if !reflect.DeepEqual(forwarded, expected) {
+		t.Errorf("unexpected metadata %v", forwarded)
+	}
+}
@@ -0,0 +1,147 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// KnownNodes reports whether a target is the address of a known node, it is
+// implemented by StaticResolver and FileResolver.
+type KnownNodes interface {
+	Known(target string) bool
+}
+
+// TargetPolicy restricts the targets a request may ask for, so the callers
+// can't have the proxy dial arbitrary hosts with its own identity.
+//
+// Unless the allowlist is empty, a target must be allowed by any of CIDRs,
+// Hostnames or Nodes. The hostnames aren't resolved: an IP target is only
+// allowed by CIDRs or Nodes, a named target only by Hostnames or Nodes. The
+// targets with a scheme, e.g. "dns:///host", must also have it listed in
+// Schemes, and the dns targets naming an authority are never allowed as the
+// authority would be the DNS server resolving them.
+//
+// A nil TargetPolicy allows any target.
+type TargetPolicy struct {
+	// MaxTargets caps the number of targets of a request, zero doesn't cap
+	// them.
+	MaxTargets int
+	// CIDRs lists the networks the IP targets are allowed in.
+	CIDRs []netip.Prefix
+	// Hostnames lists the allowed named targets, "*.example.com" allowing
+	// the subdomains of example.com. Unix socket targets must be listed as
+	// is, e.g. "unix:/var/run/machine.sock".
+	Hostnames []string
+	// Schemes lists the schemes the targets may have, e.g. "dns" or "unix",
+	// the targets without a scheme being always allowed to.
+	Schemes []string
+	// Nodes allows the nodes it knows, e.g. the ones of the Resolver.
+	Nodes KnownNodes
+	// Authorize is called with the method and the targets of the request
+	// once they passed the other checks, an error rejects the request.
+	Authorize func(ctx context.Context, method string, targets []string) error
+}
+
+// Check fails with PermissionDenied when a target isn't allowed, and with
+// InvalidArgument when the request asks for too many targets.
+func (p *TargetPolicy) Check(ctx context.Context, method string, targets []string) error {
+	if p == nil {
+		return nil
+	}
+
+	if p.MaxTargets > 0 && len(targets) > p.MaxTargets {
+		return status.Errorf(codes.InvalidArgument, "%d targets requested, at most %d are allowed", len(targets), p.MaxTargets)
+	}
+
+	for _, target := range targets {
+		if !p.allowed(target) {
+			return status.Errorf(codes.PermissionDenied, "target %q is not allowed", target)
+		}
+	}
+
+	if p.Authorize != nil {
+		return p.Authorize(ctx, method, targets)
+	}
+
+	return nil
+}
+
+func (p *TargetPolicy) allowed(target string) bool {
+	if len(p.CIDRs) == 0 && len(p.Hostnames) == 0 && p.Nodes == nil {
+		return true
+	}
+
+	if !p.allowedScheme(target) {
+		return false
+	}
+
+	if p.Nodes != nil && p.Nodes.Known(target) {
+		return true
+	}
+
+	host, err := TargetHost(target)
+	if err != nil {
+		return false
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addr = addr.Unmap()
+
+		for _, prefix := range p.CIDRs {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, hostname := range p.Hostnames {
+		if matchHostname(hostname, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowedScheme reports whether the scheme of the target, if any, is listed
+// in Schemes.
+func (p *TargetPolicy) allowedScheme(target string) bool {
+	scheme := targetScheme(target)
+	if scheme == "" {
+		return true
+	}
+
+	if scheme == "dns" && strings.HasPrefix(target, "dns://") && !strings.HasPrefix(target, "dns:///") {
+		return false
+	}
+
+	for _, allowed := range p.Schemes {
+		if allowed == scheme {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchHostname reports whether host matches the pattern, "*." matching any
+// subdomain.
+func matchHostname(pattern, host string) bool {
+	pattern, host = strings.ToLower(strings.TrimSuffix(pattern, ".")), strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok && strings.HasPrefix(suffix, ".") {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+
+	return pattern == host
+}
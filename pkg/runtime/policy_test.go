@@ -0,0 +1,133 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime_test
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+)
+
+func TestTargetPolicy(t *testing.T) {
+	policy := &runtime.TargetPolicy{
+		MaxTargets: 3,
+		CIDRs:      []netip.Prefix{netip.MustParsePrefix("10.5.0.0/24"), netip.MustParsePrefix("fd00::/64")},
+		Hostnames:  []string{"*.cluster.local", "bastion", "unix:/var/run/machine.sock"},
+		Schemes:    []string{"dns", "unix"},
+		Nodes:      resolver,
+	}
+
+	for _, tt := range []struct {
+		target string
+		code   codes.Code
+	}{
+		{target: "10.5.0.2"},
+		{target: "10.5.0.2:50000"},
+		{target: "[fd00::2]:50000"},
+		{target: "cp-1.cluster.local:50000"},
+		{target: "dns:///Bastion:50000"},
+		{target: "unix:/var/run/machine.sock"},
+		{target: "10.5.0.9"},
+		{target: "10.6.0.2", code: codes.PermissionDenied},
+		{target: "169.254.169.254", code: codes.PermissionDenied},
+		{target: "[::ffff:169.254.169.254]:80", code: codes.PermissionDenied},
+		{target: "cluster.local", code: codes.PermissionDenied},
+		{target: "evil.com", code: codes.PermissionDenied},
+		{target: "unix:/var/run/docker.sock", code: codes.PermissionDenied},
+		{target: "dns://evil.com/bastion:50000", code: codes.PermissionDenied},
+		{target: "dns://10.5.0.2/cp-1.cluster.local", code: codes.PermissionDenied},
+		{target: "unix-abstract:bastion", code: codes.PermissionDenied},
+	} {
+		err := policy.Check(context.Background(), "/machine.Machine/Reboot", []string{tt.target})
+		if status.Code(err) != tt.code {
+			t.Errorf("%s: expected %s, got %v", tt.target, tt.code, err)
+		}
+	}
+
+	err := policy.Check(context.Background(), "/machine.Machine/Reboot", targets)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected an invalid argument error, got %v", err)
+	}
+}
+
+func TestTargetPolicySchemes(t *testing.T) {
+	policy := &runtime.TargetPolicy{
+		Hostnames: []string{"bastion", "unix:/var/run/machine.sock"},
+	}
+
+	for target, allowed := range map[string]bool{
+		"bastion:50000":              true,
+		"dns:///bastion:50000":       false,
+		"unix:/var/run/machine.sock": false,
+	} {
+		err := policy.Check(context.Background(), "/machine.Machine/Reboot", []string{target})
+		if (err == nil) != allowed {
+			t.Errorf("%s: unexpected outcome %v", target, err)
+		}
+	}
+}
+
+func TestTargetPolicyKnownNodes(t *testing.T) {
+	policy := &runtime.TargetPolicy{Nodes: resolver}
+
+	for target, allowed := range map[string]bool{
+		"10.5.0.3":       true,
+		"10.5.0.3:50000": true,
+		"10.5.0.3:2379":  false,
+		"10.5.0.9":       false,
+		"localhost":      false,
+	} {
+		err := policy.Check(context.Background(), "/machine.Machine/Reboot", []string{target})
+		if (err == nil) != allowed {
+			t.Errorf("%s: unexpected outcome %v", target, err)
+		}
+	}
+}
+
+func TestTargetPolicyKnownNodesWithoutPort(t *testing.T) {
+	policy := &runtime.TargetPolicy{Nodes: &runtime.StaticResolver{Nodes: []runtime.Node{{Address: "10.5.0.3"}, {Address: "10.5.0.4:50001"}}}}
+
+	for target, allowed := range map[string]bool{
+		"10.5.0.3":       true,
+		"10.5.0.3:50000": false,
+		"10.5.0.4:50001": true,
+		"10.5.0.4":       false,
+	} {
+		err := policy.Check(context.Background(), "/machine.Machine/Reboot", []string{target})
+		if (err == nil) != allowed {
+			t.Errorf("%s: unexpected outcome %v", target, err)
+		}
+	}
+}
+
+func TestTargetPolicyAuthorize(t *testing.T) {
+	policy := &runtime.TargetPolicy{
+		Authorize: func(_ context.Context, method string, targets []string) error {
+			if method == "/machine.Machine/Reboot" && len(targets) > 1 {
+				return status.Error(codes.PermissionDenied, "one node at a time")
+			}
+
+			return nil
+		},
+	}
+
+	if err := policy.Check(context.Background(), "/machine.Machine/Version", targets); err != nil {
+		t.Error(err)
+	}
+
+	if err := policy.Check(context.Background(), "/machine.Machine/Reboot", targets); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected a permission denied error, got %v", err)
+	}
+
+	// a nil policy allows anything
+	if err := (*runtime.TargetPolicy)(nil).Check(context.Background(), "/machine.Machine/Reboot", targets); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,236 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// DefaultIdleTimeout is how long the connections nobody uses are kept open.
+const DefaultIdleTimeout = 5 * time.Minute
+
+// ErrPoolClosed is returned when a connection is requested from a closed pool.
+var ErrPoolClosed = errors.New("connection pool is closed")
+
+// Pool shares the client connections to the targets between the requests.
+// The connections are keyed by target and credentials, and get closed once
+// unused for the idle timeout.
+//
+// A connection dialed with new credentials, e.g. after a rotation, retires
+// the connections to the same target dialed with the previous ones: they are
+// closed as soon as the requests still using them are done.
+//
+// A nil Pool doesn't share anything: each connection is dialed on request and
+// closed when released.
+type Pool struct {
+	idleTimeout time.Duration
+
+	mu     sync.Mutex
+	conns  map[poolKey]*pooledConn
+	closed bool
+}
+
+type poolKey struct {
+	target string
+	creds  credentials.TransportCredentials
+}
+
+type pooledConn struct {
+	conn    *grpc.ClientConn
+	refs    int
+	timer   *time.Timer
+	retired bool
+}
+
+// NewPool creates a connection pool. An idleTimeout of zero keeps the
+// connections open until the pool gets closed.
+func NewPool(idleTimeout time.Duration) *Pool {
+	return &Pool{
+		idleTimeout: idleTimeout,
+		conns:       make(map[poolKey]*pooledConn),
+	}
+}
+
+// Get returns a connection to the target, dialing it with dialer, or the
+// NetworkDialer if nil, when the pool doesn't hold one yet. The returned func
+// hands the connection back to the pool, it must be called once the request is
+// done.
+//
+// The connection is dialed without holding the pool, so a dialer blocking on
+// an unreachable target doesn't hold up the other requests. The credentials
+// key the pooled connections, they must be comparable.
+func (p *Pool) Get(dialer Dialer, target string, creds credentials.TransportCredentials, opts ...grpc.DialOption) (*grpc.ClientConn, func(), error) {
+	if dialer == nil {
+		dialer = NetworkDialer
+	}
+
+	if p == nil {
+		conn, err := dialer.Dial(target, creds, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return conn, func() { conn.Close() }, nil //nolint:errcheck
+	}
+
+	if creds != nil && !reflect.ValueOf(creds).Comparable() {
+		return nil, nil, fmt.Errorf("credentials %T of %s can't be pooled, they aren't comparable", creds, target)
+	}
+
+	key := poolKey{target: target, creds: creds}
+
+	p.mu.Lock()
+
+	if p.closed {
+		p.mu.Unlock()
+
+		return nil, nil, ErrPoolClosed
+	}
+
+	if pc, ok := p.conns[key]; ok {
+		release := p.acquire(key, pc)
+
+		p.mu.Unlock()
+
+		return pc.conn, release, nil
+	}
+
+	p.mu.Unlock()
+
+	conn, err := dialer.Dial(target, creds, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		conn.Close() //nolint:errcheck
+
+		return nil, nil, ErrPoolClosed
+	}
+
+	pc, ok := p.conns[key]
+	if ok {
+		// another request dialed the target meanwhile
+		conn.Close() //nolint:errcheck
+	} else {
+		pc = &pooledConn{conn: conn}
+		p.conns[key] = pc
+
+		p.retire(key)
+	}
+
+	return pc.conn, p.acquire(key, pc), nil
+}
+
+// acquire takes a reference on the connection, it returns the func releasing
+// it.
+func (p *Pool) acquire(key poolKey, pc *pooledConn) func() {
+	if pc.timer != nil {
+		pc.timer.Stop()
+		pc.timer = nil
+	}
+
+	pc.refs++
+
+	var once sync.Once
+
+	return func() { once.Do(func() { p.release(key, pc) }) }
+}
+
+// Close closes all the connections, the ones still in use included.
+func (p *Pool) Close() error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+
+	var errs []error
+
+	for key, pc := range p.conns {
+		if pc.timer != nil {
+			pc.timer.Stop()
+		}
+
+		if err := pc.conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+
+		delete(p.conns, key)
+	}
+
+	return errors.Join(errs...)
+}
+
+// retire drops the connections to the target of key dialed with other
+// credentials, the ones still in use get closed once released.
+func (p *Pool) retire(key poolKey) {
+	for other, pc := range p.conns {
+		if other.target != key.target || other == key {
+			continue
+		}
+
+		delete(p.conns, other)
+
+		if pc.timer != nil {
+			pc.timer.Stop()
+			pc.timer = nil
+		}
+
+		if pc.refs > 0 {
+			pc.retired = true
+
+			continue
+		}
+
+		pc.conn.Close() //nolint:errcheck
+	}
+}
+
+func (p *Pool) release(key poolKey, pc *pooledConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pc.refs--
+
+	if pc.retired && pc.refs == 0 {
+		pc.conn.Close() //nolint:errcheck
+
+		return
+	}
+
+	if pc.refs > 0 || p.closed || p.idleTimeout <= 0 {
+		return
+	}
+
+	pc.timer = time.AfterFunc(p.idleTimeout, func() { p.evict(key, pc) })
+}
+
+func (p *Pool) evict(key poolKey, pc *pooledConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// the connection got picked up again while the timer fired
+	if pc.refs > 0 || p.conns[key] != pc {
+		return
+	}
+
+	delete(p.conns, key)
+
+	pc.conn.Close() //nolint:errcheck
+}
@@ -0,0 +1,252 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime_test
+
+import (
+	"crypto/tls"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+)
+
+func TestPoolReuse(t *testing.T) {
+	pool := runtime.NewPool(0)
+	defer pool.Close() //nolint:errcheck
+
+	creds := insecure.NewCredentials()
+
+	conn1, release1, err := pool.Get(nil, "10.5.0.2:50000", creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn2, release2, err := pool.Get(nil, "10.5.0.2:50000", creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn3, release3, err := pool.Get(nil, "10.5.0.3:50000", creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conn1 != conn2 {
+		t.Error("connection to the same target not reused")
+	}
+
+	if conn1 == conn3 {
+		t.Error("connection shared between targets")
+	}
+
+	release1()
+	release2()
+	release3()
+
+	if err = pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if conn1.GetState() != connectivity.Shutdown {
+		t.Error("connection not closed with the pool")
+	}
+
+	if _, _, err = pool.Get(nil, "10.5.0.2:50000", creds); !errors.Is(err, runtime.ErrPoolClosed) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestPoolEviction(t *testing.T) {
+	pool := runtime.NewPool(10 * time.Millisecond)
+	defer pool.Close() //nolint:errcheck
+
+	creds := insecure.NewCredentials()
+
+	conn, release, err := pool.Get(nil, "10.5.0.2:50000", creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// still in use
+	time.Sleep(50 * time.Millisecond)
+
+	if conn.GetState() == connectivity.Shutdown {
+		t.Fatal("connection in use evicted")
+	}
+
+	release()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for conn.GetState() != connectivity.Shutdown {
+		if time.Now().After(deadline) {
+			t.Fatal("idle connection not evicted")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPoolRotation(t *testing.T) {
+	pool := runtime.NewPool(0)
+	defer pool.Close() //nolint:errcheck
+
+	oldCreds, newCreds := credentials.NewTLS(&tls.Config{}), credentials.NewTLS(&tls.Config{})
+
+	inUse, releaseInUse, err := pool.Get(nil, "10.5.0.2:50000", oldCreds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idle, releaseIdle, err := pool.Get(nil, "10.5.0.3:50000", oldCreds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	releaseIdle()
+
+	conn, release, err := pool.Get(nil, "10.5.0.2:50000", newCreds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer release()
+
+	if conn == inUse {
+		t.Fatal("connection reused with other credentials")
+	}
+
+	// the request in flight keeps its connection
+	if inUse.GetState() == connectivity.Shutdown {
+		t.Fatal("connection in use closed")
+	}
+
+	releaseInUse()
+
+	if inUse.GetState() != connectivity.Shutdown {
+		t.Error("retired connection not closed once released")
+	}
+
+	// the connections to the other targets are left alone
+	if idle.GetState() == connectivity.Shutdown {
+		t.Error("connection to another target closed")
+	}
+}
+
+func TestPoolDialer(t *testing.T) {
+	var dialed []string
+
+	dialer := runtime.DialerFunc(func(target string, creds credentials.TransportCredentials, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+		dialed = append(dialed, target)
+
+		return runtime.NetworkDialer.Dial(target, creds, opts...)
+	})
+
+	pool := runtime.NewPool(0)
+	defer pool.Close() //nolint:errcheck
+
+	creds := insecure.NewCredentials()
+
+	for range 2 {
+		_, release, err := pool.Get(dialer, "10.5.0.2:50000", creds)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		release()
+	}
+
+	// a nil pool dials through the dialer as well
+	_, release, err := (*runtime.Pool)(nil).Get(dialer, "10.5.0.3:50000", creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release()
+
+	if !reflect.DeepEqual(dialed, []string{"10.5.0.2:50000", "10.5.0.3:50000"}) {
+		t.Errorf("unexpected dials %v", dialed)
+	}
+}
+
+func TestPoolBlockingDialer(t *testing.T) {
+	unblock := make(chan struct{})
+
+	dialer := runtime.DialerFunc(func(target string, creds credentials.TransportCredentials, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+		if target == "10.5.0.9:50000" {
+			<-unblock
+		}
+
+		return runtime.NetworkDialer.Dial(target, creds, opts...)
+	})
+
+	pool := runtime.NewPool(0)
+	defer pool.Close() //nolint:errcheck
+
+	creds := insecure.NewCredentials()
+
+	blocked := make(chan error, 1)
+
+	go func() {
+		_, release, err := pool.Get(dialer, "10.5.0.9:50000", creds)
+		if err == nil {
+			release()
+		}
+
+		blocked <- err
+	}()
+
+	// the other targets don't wait for the unreachable one
+	done := make(chan error, 1)
+
+	go func() {
+		_, release, err := pool.Get(dialer, "10.5.0.2:50000", creds)
+		if err == nil {
+			release()
+		}
+
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request held up by a blocked dial")
+	}
+
+	close(unblock)
+
+	if err := <-blocked; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sliceCredentials can't be compared, e.g. as a map key.
+type sliceCredentials struct {
+	credentials.TransportCredentials
+
+	protos []string
+}
+
+func TestPoolIncomparableCredentials(t *testing.T) {
+	pool := runtime.NewPool(0)
+	defer pool.Close() //nolint:errcheck
+
+	creds := sliceCredentials{TransportCredentials: insecure.NewCredentials(), protos: []string{"h2"}}
+
+	if _, _, err := pool.Get(nil, "10.5.0.2:50000", creds); err == nil {
+		t.Fatal("expected an error for incomparable credentials")
+	}
+}
@@ -0,0 +1,222 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TargetAll is the logical target standing for every known node.
+const TargetAll = "all"
+
+// Resolver expands the logical targets of a request, such as "all",
+// "role=controlplane" or a group alias, into the addresses of the nodes to
+// proxy to.
+type Resolver interface {
+	Resolve(ctx context.Context, targets []string) ([]string, error)
+}
+
+// Node is a node known to a StaticResolver.
+type Node struct {
+	Address string            `json:"address"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// StaticResolver resolves the targets against a fixed list of nodes:
+//
+//   - "all" stands for every node,
+//   - "key=value" stands for the nodes with the label key set to value,
+//   - the name of a group stands for its members, which are resolved in turn
+//     but can't be groups themselves,
+//   - anything else is an address and is kept as is.
+//
+// The addresses are deduplicated, keeping the order of the targets.
+type StaticResolver struct {
+	Nodes  []Node              `json:"nodes"`
+	Groups map[string][]string `json:"groups,omitempty"`
+	// Port is the port the nodes listed without one are known on, usually
+	// the DefaultPort of the proxy. Zero only knows their bare address.
+	Port int `json:"port,omitempty"`
+}
+
+// Resolve implements Resolver.
+func (r *StaticResolver) Resolve(_ context.Context, targets []string) ([]string, error) {
+	var (
+		resolved []string
+		seen     = make(map[string]struct{})
+	)
+
+	add := func(addresses ...string) {
+		for _, address := range addresses {
+			if _, ok := seen[address]; !ok {
+				seen[address] = struct{}{}
+
+				resolved = append(resolved, address)
+			}
+		}
+	}
+
+	for _, target := range targets {
+		members, ok := r.Groups[target]
+		if !ok {
+			members = []string{target}
+		}
+
+		for _, member := range members {
+			addresses, err := r.resolve(member)
+			if err != nil {
+				return nil, err
+			}
+
+			add(addresses...)
+		}
+	}
+
+	return resolved, nil
+}
+
+// Known reports whether the target is the address of one of the nodes. The
+// nodes listed without a port are also known on Port, and on no other port so
+// the callers can't reach the other services of the nodes.
+func (r *StaticResolver) Known(target string) bool {
+	for _, node := range r.Nodes {
+		if node.Address == target {
+			return true
+		}
+
+		if r.Port == 0 {
+			continue
+		}
+
+		if nodeHost, err := TargetHost(node.Address); err == nil && nodeHost == node.Address {
+			if address, err := DialTarget(node.Address, r.Port); err == nil && address == target {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// resolve expands a single target which isn't a group.
+func (r *StaticResolver) resolve(target string) ([]string, error) {
+	if target == TargetAll {
+		addresses := make([]string, 0, len(r.Nodes))
+
+		for _, node := range r.Nodes {
+			addresses = append(addresses, node.Address)
+		}
+
+		return addresses, nil
+	}
+
+	key, value, ok := strings.Cut(target, "=")
+	if !ok {
+		return []string{target}, nil
+	}
+
+	var addresses []string
+
+	for _, node := range r.Nodes {
+		if label, ok := node.Labels[key]; ok && label == value {
+			addresses = append(addresses, node.Address)
+		}
+	}
+
+	if len(addresses) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "no node matches the target %q", target)
+	}
+
+	return addresses, nil
+}
+
+// FileResolver is a StaticResolver loaded from a JSON file, e.g.
+//
+//	{
+//	  "nodes": [
+//	    {"address": "10.5.0.2", "labels": {"role": "controlplane"}},
+//	    {"address": "10.5.0.3", "labels": {"role": "worker"}}
+//	  ],
+//	  "groups": {"cp": ["role=controlplane"]}
+//	}
+//
+// The file is reloaded once it changes. A file which fails to load keeps the
+// previous nodes in place until it gets fixed.
+type FileResolver struct {
+	path string
+
+	mu       sync.Mutex
+	modTime  time.Time
+	size     int64
+	resolver *StaticResolver
+}
+
+// NewFileResolver loads the nodes from the file at path.
+func NewFileResolver(path string) (*FileResolver, error) {
+	r := &FileResolver{path: path}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Resolve implements Resolver.
+func (r *FileResolver) Resolve(ctx context.Context, targets []string) ([]string, error) {
+	return r.current().Resolve(ctx, targets)
+}
+
+// Known implements KnownNodes.
+func (r *FileResolver) Known(target string) bool {
+	return r.current().Known(target)
+}
+
+// current returns the nodes of the file, reloading it if needed.
+func (r *FileResolver) current() *StaticResolver {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the previous nodes stay in place if the file is broken
+	r.reload() //nolint:errcheck
+
+	return r.resolver
+}
+
+// reload loads the file if it changed since the last time it was loaded.
+func (r *FileResolver) reload() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return err
+	}
+
+	if r.resolver != nil && info.ModTime().Equal(r.modTime) && info.Size() == r.size {
+		return nil
+	}
+
+	contents, err := os.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+
+	resolver := &StaticResolver{}
+
+	if err = json.Unmarshal(contents, resolver); err != nil {
+		return fmt.Errorf("failed to load the nodes from %s: %w", r.path, err)
+	}
+
+	r.resolver, r.modTime, r.size = resolver, info.ModTime(), info.Size()
+
+	return nil
+}
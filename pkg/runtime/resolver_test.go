@@ -0,0 +1,107 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+)
+
+var resolver = &runtime.StaticResolver{
+	Nodes: []runtime.Node{
+		{Address: "10.5.0.2", Labels: map[string]string{"role": "controlplane"}},
+		{Address: "10.5.0.3", Labels: map[string]string{"role": "worker"}},
+		{Address: "10.5.0.4", Labels: map[string]string{"role": "worker"}},
+	},
+	Groups: map[string][]string{
+		"edge": {"10.5.0.4", "10.5.0.9"},
+		"cp":   {"role=controlplane"},
+	},
+	Port: 50000,
+}
+
+func TestStaticResolver(t *testing.T) {
+	for _, tt := range []struct {
+		targets  []string
+		expected []string
+	}{
+		{targets: []string{"all"}, expected: []string{"10.5.0.2", "10.5.0.3", "10.5.0.4"}},
+		{targets: []string{"role=worker"}, expected: []string{"10.5.0.3", "10.5.0.4"}},
+		{targets: []string{"cp", "edge"}, expected: []string{"10.5.0.2", "10.5.0.4", "10.5.0.9"}},
+		{targets: []string{"10.5.0.3", "role=worker", "[fd00::1]:50000"}, expected: []string{"10.5.0.3", "10.5.0.4", "[fd00::1]:50000"}},
+	} {
+		resolved, err := resolver.Resolve(context.Background(), tt.targets)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(resolved, tt.expected) {
+			t.Errorf("%v resolved to %v, expected %v", tt.targets, resolved, tt.expected)
+		}
+	}
+
+	if _, err := resolver.Resolve(context.Background(), []string{"role=etcd"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected an invalid argument error, got %v", err)
+	}
+}
+
+func TestFileResolverReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nodes.json")
+
+	write := func(contents string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		// the writes can land within the resolution of the modification time
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+
+	write(`{"nodes": [{"address": "10.5.0.2"}]}`, now)
+
+	r, err := runtime.NewFileResolver(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolve := func(expected ...string) {
+		t.Helper()
+
+		resolved, err := r.Resolve(context.Background(), []string{"all"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(resolved, expected) {
+			t.Errorf("resolved to %v, expected %v", resolved, expected)
+		}
+	}
+
+	resolve("10.5.0.2")
+
+	write(`{"nodes": [{"address": "10.5.0.2"}, {"address": "10.5.0.3"}]}`, now.Add(time.Second))
+	resolve("10.5.0.2", "10.5.0.3")
+
+	// a broken file keeps the previous nodes
+	write(`{"nodes": [`, now.Add(2*time.Second))
+	resolve("10.5.0.2", "10.5.0.3")
+
+	if _, err = runtime.NewFileResolver(path); err == nil {
+		t.Error("expected an error loading a broken file")
+	}
+}
@@ -0,0 +1,97 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys recording the proxies a request went through.
+const (
+	// MetadataVia lists the names of the proxies which forwarded the request,
+	// in order.
+	MetadataVia = "proxy-via"
+	// MetadataProxyFrom marks the requests forwarded by a proxy, it is only
+	// kept for the proxies which don't know about MetadataVia.
+	MetadataProxyFrom = "proxyfrom"
+)
+
+// DefaultMaxHops only lets a single proxy forward a request, the request then
+// gets handled by the targets.
+const DefaultMaxHops = 1
+
+// Via returns the names of the proxies the request went through. A request
+// only marked with MetadataProxyFrom went through a single proxy.
+func Via(md metadata.MD) []string {
+	if via := md.Get(MetadataVia); len(via) > 0 {
+		return via
+	}
+
+	if from := md.Get(MetadataProxyFrom); len(from) > 0 {
+		return from[:1]
+	}
+
+	return nil
+}
+
+// ProxyName returns the name the proxy records in the route of the request,
+// the authority of the request unless name is set.
+func ProxyName(md metadata.MD, name string) string {
+	if name != "" {
+		return name
+	}
+
+	if authority := md.Get(":authority"); len(authority) > 0 {
+		return authority[0]
+	}
+
+	return ""
+}
+
+// Forward reports whether the proxy named name forwards the request, or
+// leaves it to the local server as it already went through maxHops proxies.
+// A request the proxy already forwarded would go around in circles, it fails
+// with FailedPrecondition.
+//
+// A request without the `targets` metadata is meant for the proxy node itself,
+// it is left to the local server rather than forwarded to its own authority.
+func Forward(md metadata.MD, name string, maxHops int) (bool, error) {
+	if maxHops <= 0 {
+		maxHops = DefaultMaxHops
+	}
+
+	if len(md.Get("targets")) == 0 {
+		return false, nil
+	}
+
+	via := Via(md)
+
+	if len(via) >= maxHops {
+		return false, nil
+	}
+
+	name = ProxyName(md, name)
+
+	if slices.Contains(via, name) {
+		path := append(slices.Clone(via), name)
+
+		return false, status.Errorf(codes.FailedPrecondition, "proxy loop: %s", strings.Join(path, " -> "))
+	}
+
+	return true, nil
+}
+
+// SetVia records the proxy named name in the route of the forwarded request.
+func SetVia(proxyMd, md metadata.MD, name string) {
+	name = ProxyName(md, name)
+
+	proxyMd.Set(MetadataVia, append(slices.Clone(Via(md)), name)...)
+	proxyMd.Set(MetadataProxyFrom, md.Get(":authority")...)
+}
@@ -0,0 +1,74 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+)
+
+func TestForward(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		md      metadata.MD
+		maxHops int
+		forward bool
+		code    codes.Code
+	}{
+		{name: "client", md: metadata.Pairs(":authority", "10.5.0.2:50000", "targets", "10.5.0.3"), forward: true},
+		{name: "no targets", md: metadata.Pairs(":authority", "10.5.0.2:50000")},
+		{name: "no targets next hop", md: metadata.Pairs(":authority", "cluster-a", "proxy-via", "bastion"), maxHops: 2},
+		{name: "proxied", md: metadata.Pairs(":authority", "10.5.0.2:50000", "proxyfrom", "10.5.0.2:50000", "targets", "10.5.0.3")},
+		{name: "hop limit", md: metadata.Pairs("proxy-via", "bastion", "proxy-via", "cluster-a", "targets", "10.5.0.3"), maxHops: 2},
+		{name: "next hop", md: metadata.Pairs("proxy-via", "bastion", "targets", "10.5.0.3"), maxHops: 2, forward: true},
+		{name: "legacy hop", md: metadata.Pairs("proxyfrom", "bastion", "targets", "10.5.0.3"), maxHops: 2, forward: true},
+		{name: "loop", md: metadata.Pairs("proxy-via", "cluster-a", "proxy-via", "bastion", "targets", "10.5.0.3"), maxHops: 3, code: codes.FailedPrecondition},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			forward, err := runtime.Forward(tt.md, "cluster-a", tt.maxHops)
+			if status.Code(err) != tt.code {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if forward != tt.forward {
+				t.Errorf("expected forward to be %v", tt.forward)
+			}
+		})
+	}
+}
+
+func TestForwardLoopPath(t *testing.T) {
+	_, err := runtime.Forward(metadata.Pairs("proxy-via", "cluster-a", "proxy-via", "bastion", "targets", "10.5.0.3"), "cluster-a", 3)
+	if !strings.Contains(status.Convert(err).Message(), "cluster-a -> bastion -> cluster-a") {
+		t.Errorf("error doesn't list the path: %v", err)
+	}
+}
+
+func TestSetVia(t *testing.T) {
+	md := metadata.Pairs(":authority", "10.5.0.2:50000", "proxy-via", "bastion")
+	proxyMd := metadata.MD{}
+
+	runtime.SetVia(proxyMd, md, "")
+
+	if via := runtime.Via(proxyMd); !reflect.DeepEqual(via, []string{"bastion", "10.5.0.2:50000"}) {
+		t.Errorf("unexpected route %v", via)
+	}
+
+	if from := proxyMd.Get("proxyfrom"); !reflect.DeepEqual(from, []string{"10.5.0.2:50000"}) {
+		t.Errorf("unexpected proxyfrom %v", from)
+	}
+
+	// the route of the incoming request is left alone
+	if via := runtime.Via(md); !reflect.DeepEqual(via, []string{"bastion"}) {
+		t.Errorf("unexpected route %v", via)
+	}
+}
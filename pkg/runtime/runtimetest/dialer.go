@@ -0,0 +1,73 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+// Package runtimetest provides the helpers to test the generated proxies,
+// kept apart from the runtime so the binaries don't link them.
+package runtimetest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// bufconnSize is the size of the buffer of each in-memory connection.
+const bufconnSize = 1 << 20
+
+// BufconnDialer connects the targets to in-memory listeners instead of the
+// network, so the proxies can be tested against servers running in the same
+// process.
+type BufconnDialer struct {
+	mu        sync.Mutex
+	listeners map[string]*bufconn.Listener
+}
+
+// NewBufconnDialer creates a dialer without any listener.
+func NewBufconnDialer() *BufconnDialer {
+	return &BufconnDialer{
+		listeners: make(map[string]*bufconn.Listener),
+	}
+}
+
+// Listen returns the listener the connections to target are made to, for a
+// server to serve it. The target is the one dialed, i.e. with the default
+// port of the proxy applied.
+func (d *BufconnDialer) Listen(target string) net.Listener {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	lis, ok := d.listeners[target]
+	if !ok {
+		lis = bufconn.Listen(bufconnSize)
+		d.listeners[target] = lis
+	}
+
+	return lis
+}
+
+// Dial connects to the listener of the target, the targets nobody listens to
+// fail as unreachable hosts would.
+func (d *BufconnDialer) Dial(target string, creds credentials.TransportCredentials, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	contextDialer := func(ctx context.Context, _ string) (net.Conn, error) {
+		d.mu.Lock()
+		lis, ok := d.listeners[target]
+		d.mu.Unlock()
+
+		if !ok {
+			return nil, fmt.Errorf("no listener for %s", target)
+		}
+
+		return lis.DialContext(ctx)
+	}
+
+	return grpc.Dial("passthrough:///"+target, append([]grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithContextDialer(contextDialer),
+	}, opts...)...)
+}
@@ -0,0 +1,33 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// SocketDir is the directory holding the unix sockets of the local services
+// when the default socket path lookup is used.
+var SocketDir = "/var/run"
+
+// SocketPathFunc returns the path of the unix socket the service, given by its
+// fully qualified name (e.g. machine.Machine), listens on.
+type SocketPathFunc func(service string) string
+
+// SocketPath is used by the generated Local<Service>Client constructors to
+// find the socket of the local services. It can be replaced to point at
+// alternative locations.
+var SocketPath SocketPathFunc = DefaultSocketPath
+
+// DefaultSocketPath places the socket of the service in SocketDir, named after
+// the service in lower case, e.g. /var/run/machine.sock for machine.Machine.
+func DefaultSocketPath(service string) string {
+	if idx := strings.LastIndex(service, "."); idx >= 0 {
+		service = service[idx+1:]
+	}
+
+	return filepath.Join(SocketDir, strings.ToLower(service)+".sock")
+}
@@ -0,0 +1,212 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details describing the errors of
+// the targets.
+const ErrorDomain = "protoc-gen-proxy"
+
+// Keys of the metadata of the ErrorInfo details.
+const (
+	ErrorInfoTarget  = "target"
+	ErrorInfoCode    = "code"
+	ErrorInfoMessage = "message"
+)
+
+// codePrecedence orders the codes an aggregated error can get, from the most
+// to the least significant. The errors a retry won't fix come first, then the
+// transient ones and Unknown last.
+var codePrecedence = []codes.Code{
+	codes.Unauthenticated,
+	codes.PermissionDenied,
+	codes.InvalidArgument,
+	codes.FailedPrecondition,
+	codes.OutOfRange,
+	codes.NotFound,
+	codes.AlreadyExists,
+	codes.Unimplemented,
+	codes.Internal,
+	codes.DataLoss,
+	codes.ResourceExhausted,
+	codes.Aborted,
+	codes.DeadlineExceeded,
+	codes.Canceled,
+	codes.Unavailable,
+	codes.Unknown,
+}
+
+// JoinErrors aggregates errors into a single gRPC status error, skipping the
+// nil ones. It returns nil when no error is left and a lone error which isn't
+// a TargetError as is.
+//
+// Each TargetError is described by an ErrorInfo detail of the ErrorDomain
+// with its target, code and message as metadata. The status gets the code
+// shared by all the errors or, when they differ, the first one of:
+// Unauthenticated, PermissionDenied, InvalidArgument, FailedPrecondition,
+// OutOfRange, NotFound, AlreadyExists, Unimplemented, Internal, DataLoss,
+// ResourceExhausted, Aborted, DeadlineExceeded, Canceled, Unavailable and
+// Unknown.
+func JoinErrors(errs ...error) error {
+	var flattened []error
+
+	for _, err := range errs {
+		flattened = appendErrors(flattened, err)
+	}
+
+	switch len(flattened) {
+	case 0:
+		return nil
+	case 1:
+		if _, ok := flattened[0].(*TargetError); !ok { //nolint:errorlint
+			return flattened[0]
+		}
+	}
+
+	var (
+		messages []string
+		details  []*errdetails.ErrorInfo
+	)
+
+	aggregated := errorCode(flattened[0])
+
+	for _, err := range flattened {
+		c := errorCode(err)
+		if c != aggregated && precedence(c) < precedence(aggregated) {
+			aggregated = c
+		}
+
+		targetErr, ok := err.(*TargetError) //nolint:errorlint
+		if !ok {
+			messages = append(messages, errorMessage(err))
+
+			continue
+		}
+
+		messages = append(messages, targetErr.Target+": "+errorMessage(targetErr.Err))
+		details = append(details, &errdetails.ErrorInfo{
+			Reason: code.Code(c).String(),
+			Domain: ErrorDomain,
+			Metadata: map[string]string{
+				ErrorInfoTarget:  targetErr.Target,
+				ErrorInfoCode:    strconv.Itoa(int(c)),
+				ErrorInfoMessage: errorMessage(targetErr.Err),
+			},
+		})
+	}
+
+	st := status.New(aggregated, strings.Join(messages, "; "))
+
+	for _, detail := range details {
+		// only fails for nil details
+		st, _ = st.WithDetails(detail) //nolint:errcheck
+	}
+
+	return st.Err()
+}
+
+// appendErrors flattens the errors of err, keeping the TargetErrors whole.
+func appendErrors(errs []error, err error) []error {
+	if err == nil {
+		return errs
+	}
+
+	switch e := err.(type) { //nolint:errorlint
+	case *TargetError:
+		return append(errs, e)
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range e.Unwrap() {
+			errs = appendErrors(errs, wrapped)
+		}
+
+		return errs
+	}
+
+	// aggregated by a previous call
+	if targetErrs := statusTargetErrors(err); len(targetErrs) > 0 {
+		for _, targetErr := range targetErrs {
+			errs = append(errs, targetErr)
+		}
+
+		return errs
+	}
+
+	return append(errs, err)
+}
+
+// statusTargetErrors decodes the errors of the targets from the details of a
+// status built by JoinErrors.
+func statusTargetErrors(err error) []*TargetError {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+
+	var targetErrs []*TargetError
+
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.GetDomain() != ErrorDomain {
+			continue
+		}
+
+		c, err := strconv.Atoi(info.GetMetadata()[ErrorInfoCode])
+		if err != nil {
+			c = int(codes.Unknown)
+		}
+
+		targetErrs = append(targetErrs, &TargetError{
+			Target: info.GetMetadata()[ErrorInfoTarget],
+			Err:    status.Error(codes.Code(c), info.GetMetadata()[ErrorInfoMessage]),
+		})
+	}
+
+	return targetErrs
+}
+
+func errorCode(err error) codes.Code {
+	if targetErr, ok := err.(*TargetError); ok { //nolint:errorlint
+		err = targetErr.Err
+	}
+
+	if st, ok := status.FromError(err); ok {
+		return st.Code()
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return status.FromContextError(err).Code()
+	}
+
+	return codes.Unknown
+}
+
+func errorMessage(err error) string {
+	if st, ok := status.FromError(err); ok {
+		return st.Message()
+	}
+
+	return err.Error()
+}
+
+func precedence(c codes.Code) int {
+	for i, other := range codePrecedence {
+		if c == other {
+			return i
+		}
+	}
+
+	return len(codePrecedence)
+}
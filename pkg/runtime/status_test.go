@@ -0,0 +1,120 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+)
+
+func TestJoinErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		errs    []error
+		code    codes.Code
+		message string
+		targets int
+	}{
+		{
+			name:    "same code",
+			errs:    []error{target("10.5.0.2", codes.Unavailable, "refused"), target("10.5.0.3", codes.Unavailable, "reset")},
+			code:    codes.Unavailable,
+			message: "10.5.0.2: refused; 10.5.0.3: reset",
+			targets: 2,
+		},
+		{
+			name: "precedence",
+			errs: []error{
+				target("10.5.0.2", codes.Unavailable, "refused"),
+				&runtime.TargetError{Target: "10.5.0.3", Err: context.DeadlineExceeded},
+				target("10.5.0.4", codes.PermissionDenied, "denied"),
+			},
+			code:    codes.PermissionDenied,
+			message: "10.5.0.2: refused; 10.5.0.3: context deadline exceeded; 10.5.0.4: denied",
+			targets: 3,
+		},
+		{
+			name:    "single target",
+			errs:    []error{nil, target("10.5.0.2", codes.NotFound, "no such file")},
+			code:    codes.NotFound,
+			message: "10.5.0.2: no such file",
+			targets: 1,
+		},
+		{
+			name:    "untargeted",
+			errs:    []error{errors.New("stream closed"), target("10.5.0.2", codes.Internal, "panic")},
+			code:    codes.Internal,
+			message: "stream closed; 10.5.0.2: panic",
+			targets: 1,
+		},
+		{
+			name: "nested",
+			errs: []error{
+				runtime.JoinErrors(target("10.5.0.2", codes.Unavailable, "refused"), target("10.5.0.3", codes.Unavailable, "reset")),
+				errors.Join(target("10.5.0.4", codes.Unavailable, "unreachable")),
+			},
+			code:    codes.Unavailable,
+			message: "10.5.0.2: refused; 10.5.0.3: reset; 10.5.0.4: unreachable",
+			targets: 3,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(runtime.JoinErrors(tt.errs...))
+
+			if st.Code() != tt.code {
+				t.Errorf("expected code %s, got %s", tt.code, st.Code())
+			}
+
+			if st.Message() != tt.message {
+				t.Errorf("unexpected message %q", st.Message())
+			}
+
+			if len(st.Details()) != tt.targets {
+				t.Fatalf("expected %d details, got %v", tt.targets, st.Details())
+			}
+
+			for _, detail := range st.Details() {
+				info, ok := detail.(*errdetails.ErrorInfo)
+				if !ok || info.GetDomain() != runtime.ErrorDomain || info.GetMetadata()[runtime.ErrorInfoTarget] == "" {
+					t.Errorf("unexpected detail %v", detail)
+				}
+			}
+		})
+	}
+}
+
+func TestJoinErrorsPassthrough(t *testing.T) {
+	if err := runtime.JoinErrors(nil, nil); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if err := runtime.JoinErrors(runtime.ErrUnknownMethod); err != runtime.ErrUnknownMethod { //nolint:errorlint
+		t.Errorf("expected the error as is, got %v", err)
+	}
+}
+
+func TestTargetErrorsFromStatus(t *testing.T) {
+	err := runtime.JoinErrors(target("10.5.0.2", codes.Unavailable, "refused"), target("10.5.0.3", codes.NotFound, "missing"))
+
+	targetErrs := runtime.TargetErrors(err)
+	if len(targetErrs) != 2 {
+		t.Fatalf("unexpected target errors %v", targetErrs)
+	}
+
+	if targetErrs[1].Target != "10.5.0.3" || status.Code(targetErrs[1].Err) != codes.NotFound || status.Convert(targetErrs[1].Err).Message() != "missing" {
+		t.Errorf("unexpected target error %v", targetErrs[1])
+	}
+}
+
+func target(target string, c codes.Code, message string) error {
+	return &runtime.TargetError{Target: target, Err: status.Error(c, message)}
+}
@@ -0,0 +1,107 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// StreamSource is one of the proxied streams merged by MergeStreams.
+type StreamSource struct {
+	// Target the stream is proxied to.
+	Target string
+	// Context the stream is opened with.
+	Context context.Context
+	// Open opens the stream with a context derived from Context.
+	Open func(context.Context) (grpc.ClientStream, error)
+}
+
+type taggedMessage struct {
+	msg    proto.Message
+	target string
+}
+
+// MergeStreams opens all the sources and forwards their messages to srv as
+// they come. newMsg allocates the messages received from the sources and tag,
+// if set, marks each of them with the target it comes from.
+//
+// A failing target doesn't interrupt the other streams: the errors of the
+// targets are returned, aggregated by JoinErrors, once all the streams are
+// done. The streams still open are cancelled when srv goes away.
+func MergeStreams(srv grpc.ServerStream, sources []StreamSource, newMsg func() proto.Message, tag func(msg proto.Message, target string)) error {
+	msgCh := make(chan taggedMessage)
+	errCh := make(chan error, len(sources))
+
+	for _, source := range sources {
+		ctx, cancel := context.WithCancel(source.Context)
+		defer cancel()
+
+		go func(ctx context.Context, source StreamSource) {
+			if err := recvStream(ctx, source, newMsg, msgCh); err != nil {
+				errCh <- &TargetError{Target: source.Target, Err: err}
+
+				return
+			}
+
+			errCh <- nil
+		}(ctx, source)
+	}
+
+	var errs []error
+
+	for done := 0; done < len(sources); {
+		select {
+		case m := <-msgCh:
+			if tag != nil {
+				tag(m.msg, m.target)
+			}
+
+			if err := srv.SendMsg(m.msg); err != nil {
+				return err
+			}
+		case err := <-errCh:
+			done++
+
+			if err != nil {
+				errs = append(errs, err)
+			}
+		case <-srv.Context().Done():
+			return status.FromContextError(srv.Context().Err()).Err()
+		}
+	}
+
+	return JoinErrors(errs...)
+}
+
+func recvStream(ctx context.Context, source StreamSource, newMsg func() proto.Message, msgCh chan<- taggedMessage) error {
+	stream, err := source.Open(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		msg := newMsg()
+
+		err = stream.RecvMsg(msg)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		select {
+		case msgCh <- taggedMessage{msg: msg, target: source.Target}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
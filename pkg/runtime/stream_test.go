@@ -0,0 +1,150 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+)
+
+// serverStream records the messages sent to the caller.
+type serverStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+
+	mu   sync.Mutex
+	sent []string
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent = append(s.sent, m.(*wrapperspb.StringValue).GetValue())
+
+	return nil
+}
+
+// clientStream replays the messages, then fails with err or io.EOF.
+type clientStream struct {
+	grpc.ClientStream
+
+	msgs []string
+	err  error
+}
+
+func (c *clientStream) RecvMsg(m interface{}) error {
+	if len(c.msgs) == 0 {
+		if c.err != nil {
+			return c.err
+		}
+
+		return io.EOF
+	}
+
+	m.(*wrapperspb.StringValue).Value = c.msgs[0]
+	c.msgs = c.msgs[1:]
+
+	return nil
+}
+
+func source(target string, stream *clientStream, err error) runtime.StreamSource {
+	return runtime.StreamSource{
+		Target:  target,
+		Context: context.Background(),
+		Open: func(context.Context) (grpc.ClientStream, error) {
+			if err != nil {
+				return nil, err
+			}
+
+			return stream, nil
+		},
+	}
+}
+
+func TestMergeStreams(t *testing.T) {
+	srv := &serverStream{ctx: context.Background()}
+
+	errBroken := errors.New("broken pipe")
+	errDial := errors.New("connection refused")
+
+	sources := []runtime.StreamSource{
+		source("10.5.0.2", &clientStream{msgs: []string{"a", "b"}}, nil),
+		source("10.5.0.3", &clientStream{msgs: []string{"c"}, err: errBroken}, nil),
+		source("10.5.0.4", nil, errDial),
+	}
+
+	newMsg := func() proto.Message { return &wrapperspb.StringValue{} }
+	tag := func(msg proto.Message, target string) {
+		m := msg.(*wrapperspb.StringValue)
+		m.Value = target + "/" + m.Value
+	}
+
+	err := runtime.MergeStreams(srv, sources, newMsg, tag)
+
+	sort.Strings(srv.sent)
+
+	expected := []string{"10.5.0.2/a", "10.5.0.2/b", "10.5.0.3/c"}
+	if len(srv.sent) != len(expected) {
+		t.Fatalf("unexpected messages %v", srv.sent)
+	}
+
+	for i := range expected {
+		if srv.sent[i] != expected[i] {
+			t.Fatalf("unexpected messages %v", srv.sent)
+		}
+	}
+
+	failed := make(map[string]string)
+
+	for _, targetErr := range runtime.TargetErrors(err) {
+		failed[targetErr.Target] = status.Convert(targetErr.Err).Message()
+	}
+
+	for target, expectedErr := range map[string]error{"10.5.0.3": errBroken, "10.5.0.4": errDial} {
+		if failed[target] != expectedErr.Error() {
+			t.Errorf("error of %s not reported: %v", target, err)
+		}
+	}
+}
+
+func TestMergeStreamsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	srv := &serverStream{ctx: ctx}
+
+	// the backend stream hangs until it gets cancelled
+	hanging := runtime.StreamSource{
+		Target:  "10.5.0.2",
+		Context: context.Background(),
+		Open: func(ctx context.Context) (grpc.ClientStream, error) {
+			<-ctx.Done()
+
+			return nil, ctx.Err()
+		},
+	}
+
+	err := runtime.MergeStreams(srv, []runtime.StreamSource{hanging}, func() proto.Message { return &wrapperspb.StringValue{} }, nil)
+	if err == nil {
+		t.Fatal("expected an error when the caller goes away")
+	}
+}
@@ -0,0 +1,235 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// DialTarget converts a target requested through the `targets` metadata into
+// a gRPC dial target.
+//
+// The following forms are supported:
+//
+//	host, host:port
+//	ipv4, ipv4:port
+//	ipv6, [ipv6], [ipv6]:port
+//	unix:path, unix:///path, unix-abstract:name
+//	dns:///host[:port], dns://authority/host[:port]
+//
+// defaultPort is appended to the targets which don't specify a port, unless
+// it is zero.
+func DialTarget(target string, defaultPort int) (string, error) {
+	switch {
+	case target == "":
+		return "", fmt.Errorf("empty target")
+	case strings.HasPrefix(target, "unix:"), strings.HasPrefix(target, "unix-abstract:"):
+		if err := checkSocket(target); err != nil {
+			return "", err
+		}
+
+		return target, nil
+	case strings.HasPrefix(target, "dns:"):
+		prefix, endpoint, err := splitDNSTarget(target)
+		if err != nil {
+			return "", err
+		}
+
+		hostport, err := joinDefaultPort(endpoint, defaultPort)
+		if err != nil {
+			return "", fmt.Errorf("invalid target %q: %w", target, err)
+		}
+
+		return prefix + hostport, nil
+	default:
+		hostport, err := joinDefaultPort(target, defaultPort)
+		if err != nil {
+			return "", fmt.Errorf("invalid target %q: %w", target, err)
+		}
+
+		return hostport, nil
+	}
+}
+
+// TargetHost returns the host a target requested through the `targets`
+// metadata points at, without its port. The unix socket targets are returned
+// as is.
+func TargetHost(target string) (string, error) {
+	switch {
+	case target == "":
+		return "", fmt.Errorf("empty target")
+	case strings.HasPrefix(target, "unix:"), strings.HasPrefix(target, "unix-abstract:"):
+		if err := checkSocket(target); err != nil {
+			return "", err
+		}
+
+		return target, nil
+	case strings.HasPrefix(target, "dns:"):
+		_, endpoint, err := splitDNSTarget(target)
+		if err != nil {
+			return "", err
+		}
+
+		target = endpoint
+	}
+
+	host, _, err := splitHostPort(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid target %q: %w", target, err)
+	}
+
+	return host, nil
+}
+
+// targetScheme returns the scheme of a target, empty for the host[:port]
+// targets.
+func targetScheme(target string) string {
+	for _, scheme := range []string{"unix-abstract", "unix", "dns"} {
+		if strings.HasPrefix(target, scheme+":") {
+			return scheme
+		}
+	}
+
+	return ""
+}
+
+// checkSocket checks that a unix socket target names a socket.
+func checkSocket(target string) error {
+	_, name, _ := strings.Cut(target, ":")
+
+	if strings.Trim(name, "/") == "" {
+		return fmt.Errorf("invalid target %q: missing socket", target)
+	}
+
+	return nil
+}
+
+// splitDNSTarget splits a dns:[//authority/]host[:port] target into its
+// prefix and its endpoint.
+func splitDNSTarget(target string) (string, string, error) {
+	prefix, endpoint := "dns:", strings.TrimPrefix(target, "dns:")
+
+	if strings.HasPrefix(endpoint, "//") {
+		idx := strings.Index(endpoint[2:], "/")
+		if idx < 0 {
+			return "", "", fmt.Errorf("invalid target %q: missing endpoint", target)
+		}
+
+		prefix, endpoint = prefix+endpoint[:idx+3], endpoint[idx+3:]
+	}
+
+	return prefix, endpoint, nil
+}
+
+// joinDefaultPort adds the default port to the address if it doesn't have
+// one already.
+func joinDefaultPort(addr string, defaultPort int) (string, error) {
+	host, port, err := splitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+
+	if port == "" {
+		if defaultPort == 0 {
+			if strings.Contains(host, ":") {
+				return "[" + host + "]", nil
+			}
+
+			return host, nil
+		}
+
+		port = strconv.Itoa(defaultPort)
+	}
+
+	return net.JoinHostPort(host, port), nil
+}
+
+// splitHostPort splits the address into its host and its port, which is
+// empty when the address doesn't have one. The host must be an IP address or a
+// hostname, the port a valid one.
+func splitHostPort(addr string) (string, string, error) {
+	var host, port string
+
+	switch {
+	case strings.HasPrefix(addr, "["):
+		end := strings.Index(addr, "]")
+		if end < 0 {
+			return "", "", fmt.Errorf("missing ']' in address")
+		}
+
+		host = addr[1:end]
+
+		if _, err := netip.ParseAddr(host); err != nil {
+			return "", "", fmt.Errorf("invalid IP address %q", host)
+		}
+
+		switch rest := addr[end+1:]; {
+		case rest == "":
+		case strings.HasPrefix(rest, ":"):
+			port = rest[1:]
+		default:
+			return "", "", fmt.Errorf("unexpected %q after address", rest)
+		}
+	case strings.Count(addr, ":") > 1:
+		// bare IPv6 literal
+		host = addr
+	case strings.Contains(addr, ":"):
+		var err error
+
+		if host, port, err = net.SplitHostPort(addr); err != nil {
+			return "", "", err
+		}
+	default:
+		host = addr
+	}
+
+	if host == "" {
+		return "", "", fmt.Errorf("missing host")
+	}
+
+	if err := checkHost(host); err != nil {
+		return "", "", err
+	}
+
+	// an explicit empty port, e.g. host:, is a typo rather than a request for
+	// the default port
+	if port == "" && strings.HasSuffix(addr, ":") {
+		return "", "", fmt.Errorf("missing port after ':'")
+	}
+
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+			return "", "", fmt.Errorf("invalid port %q", port)
+		}
+	}
+
+	return host, port, nil
+}
+
+// checkHost checks that the host is an IP address, IPv6 ones possibly zoned,
+// or a hostname made of letters, digits, dots, hyphens and underscores.
+func checkHost(host string) error {
+	if strings.Contains(host, ":") {
+		if _, err := netip.ParseAddr(host); err != nil {
+			return fmt.Errorf("invalid IPv6 address %q", host)
+		}
+
+		return nil
+	}
+
+	for _, c := range host {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '-', c == '_':
+		default:
+			return fmt.Errorf("invalid host %q", host)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/talos-systems/protoc-gen-proxy/pkg/runtime"
+)
+
+func TestDialTarget(t *testing.T) {
+	for _, tt := range []struct {
+		target string
+		dial   string
+		host   string
+		err    bool
+	}{
+		{target: "10.5.0.2", dial: "10.5.0.2:50000", host: "10.5.0.2"},
+		{target: "10.5.0.2:4000", dial: "10.5.0.2:4000", host: "10.5.0.2"},
+		{target: "cp-1.cluster.local", dial: "cp-1.cluster.local:50000", host: "cp-1.cluster.local"},
+		{target: "cp_1:4000", dial: "cp_1:4000", host: "cp_1"},
+		{target: "fd00::2", dial: "[fd00::2]:50000", host: "fd00::2"},
+		{target: "[fd00::2]", dial: "[fd00::2]:50000", host: "fd00::2"},
+		{target: "[fd00::2]:4000", dial: "[fd00::2]:4000", host: "fd00::2"},
+		{target: "fe80::1%eth0", dial: "[fe80::1%eth0]:50000", host: "fe80::1%eth0"},
+		{target: "[fe80::1%eth0]:4000", dial: "[fe80::1%eth0]:4000", host: "fe80::1%eth0"},
+		{target: "unix:/var/run/machine.sock", dial: "unix:/var/run/machine.sock", host: "unix:/var/run/machine.sock"},
+		{target: "unix:///var/run/machine.sock", dial: "unix:///var/run/machine.sock", host: "unix:///var/run/machine.sock"},
+		{target: "unix-abstract:machine", dial: "unix-abstract:machine", host: "unix-abstract:machine"},
+		{target: "dns:///cp-1:4000", dial: "dns:///cp-1:4000", host: "cp-1"},
+		{target: "dns:///cp-1", dial: "dns:///cp-1:50000", host: "cp-1"},
+		{target: "dns://10.96.0.10/cp-1", dial: "dns://10.96.0.10/cp-1:50000", host: "cp-1"},
+		{target: "", err: true},
+		{target: "a b", err: true},
+		{target: "host\n", err: true},
+		{target: "host:", err: true},
+		{target: "[fd00::2]:", err: true},
+		{target: "host:0", err: true},
+		{target: "host:65536", err: true},
+		{target: "host:http", err: true},
+		{target: "[fd00::2", err: true},
+		{target: "[fd00::2]x", err: true},
+		{target: "[cp-1]:4000", err: true},
+		{target: "fd00::zz", err: true},
+		{target: ":4000", err: true},
+		{target: "unix:", err: true},
+		{target: "dns://10.96.0.10", err: true},
+		{target: "dns:///", err: true},
+		{target: "dns:///a b", err: true},
+	} {
+		dial, err := runtime.DialTarget(tt.target, 50000)
+		if (err != nil) != tt.err {
+			t.Errorf("%q: unexpected error %v", tt.target, err)
+
+			continue
+		}
+
+		if dial != tt.dial {
+			t.Errorf("%q: expected %q, got %q", tt.target, tt.dial, dial)
+		}
+
+		host, err := runtime.TargetHost(tt.target)
+		if (err != nil) != tt.err {
+			t.Errorf("%q: unexpected error %v", tt.target, err)
+
+			continue
+		}
+
+		if host != tt.host {
+			t.Errorf("%q: expected host %q, got %q", tt.target, tt.host, host)
+		}
+	}
+}
+
+func TestDialTargetNoDefaultPort(t *testing.T) {
+	for target, expected := range map[string]string{
+		"10.5.0.2":       "10.5.0.2",
+		"10.5.0.2:4000":  "10.5.0.2:4000",
+		"fd00::2":        "[fd00::2]",
+		"[fd00::2]:4000": "[fd00::2]:4000",
+		"dns:///cp-1":    "dns:///cp-1",
+	} {
+		dial, err := runtime.DialTarget(target, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if dial != expected {
+			t.Errorf("%q: expected %q, got %q", target, expected, dial)
+		}
+	}
+}
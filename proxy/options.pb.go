@@ -0,0 +1,777 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: proxy/options.proto
+
+package proxy
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Mode controls how the proxy routes the calls to a method.
+type Mode int32
+
+const (
+	// FANOUT sends the request to every target and aggregates the responses.
+	Mode_FANOUT Mode = 0
+	// SINGLE sends the request to the first target only and returns its
+	// response as is.
+	Mode_SINGLE Mode = 1
+	// LOCAL_ONLY never proxies the request, it is always handled by the local
+	// server.
+	Mode_LOCAL_ONLY Mode = 2
+	// SKIP leaves the method out of the proxy routing altogether.
+	Mode_SKIP Mode = 3
+)
+
+// Enum value maps for Mode.
+var (
+	Mode_name = map[int32]string{
+		0: "FANOUT",
+		1: "SINGLE",
+		2: "LOCAL_ONLY",
+		3: "SKIP",
+	}
+	Mode_value = map[string]int32{
+		"FANOUT":     0,
+		"SINGLE":     1,
+		"LOCAL_ONLY": 2,
+		"SKIP":       3,
+	}
+)
+
+func (x Mode) Enum() *Mode {
+	p := new(Mode)
+	*p = x
+	return p
+}
+
+func (x Mode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Mode) Descriptor() protoreflect.EnumDescriptor {
+	return file_proxy_options_proto_enumTypes[0].Descriptor()
+}
+
+func (Mode) Type() protoreflect.EnumType {
+	return &file_proxy_options_proto_enumTypes[0]
+}
+
+func (x Mode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Mode.Descriptor instead.
+func (Mode) EnumDescriptor() ([]byte, []int) {
+	return file_proxy_options_proto_rawDescGZIP(), []int{0}
+}
+
+// Dispatch selects the targets which answer a FANOUT request.
+type Dispatch int32
+
+const (
+	// ALL calls every target and aggregates all their responses.
+	Dispatch_ALL Dispatch = 0
+	// ANY calls the targets at once and returns the first success, the other
+	// calls get cancelled.
+	Dispatch_ANY Dispatch = 1
+	// QUORUM calls the targets at once and returns as soon as a majority of
+	// them succeeded, the other calls get cancelled.
+	Dispatch_QUORUM Dispatch = 2
+	// ONE_OF calls a single target picked by the balancing, moving on to the
+	// next one when it fails.
+	Dispatch_ONE_OF Dispatch = 3
+)
+
+// Enum value maps for Dispatch.
+var (
+	Dispatch_name = map[int32]string{
+		0: "ALL",
+		1: "ANY",
+		2: "QUORUM",
+		3: "ONE_OF",
+	}
+	Dispatch_value = map[string]int32{
+		"ALL":    0,
+		"ANY":    1,
+		"QUORUM": 2,
+		"ONE_OF": 3,
+	}
+)
+
+func (x Dispatch) Enum() *Dispatch {
+	p := new(Dispatch)
+	*p = x
+	return p
+}
+
+func (x Dispatch) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Dispatch) Descriptor() protoreflect.EnumDescriptor {
+	return file_proxy_options_proto_enumTypes[1].Descriptor()
+}
+
+func (Dispatch) Type() protoreflect.EnumType {
+	return &file_proxy_options_proto_enumTypes[1]
+}
+
+func (x Dispatch) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Dispatch.Descriptor instead.
+func (Dispatch) EnumDescriptor() ([]byte, []int) {
+	return file_proxy_options_proto_rawDescGZIP(), []int{1}
+}
+
+// Balancing picks the target of the ONE_OF dispatch.
+type Balancing int32
+
+const (
+	// ROUND_ROBIN rotates over the targets from one request to the next.
+	Balancing_ROUND_ROBIN Balancing = 0
+	// LEAST_LOADED picks the target with the fewest calls in flight.
+	Balancing_LEAST_LOADED Balancing = 1
+)
+
+// Enum value maps for Balancing.
+var (
+	Balancing_name = map[int32]string{
+		0: "ROUND_ROBIN",
+		1: "LEAST_LOADED",
+	}
+	Balancing_value = map[string]int32{
+		"ROUND_ROBIN":  0,
+		"LEAST_LOADED": 1,
+	}
+)
+
+func (x Balancing) Enum() *Balancing {
+	p := new(Balancing)
+	*p = x
+	return p
+}
+
+func (x Balancing) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Balancing) Descriptor() protoreflect.EnumDescriptor {
+	return file_proxy_options_proto_enumTypes[2].Descriptor()
+}
+
+func (Balancing) Type() protoreflect.EnumType {
+	return &file_proxy_options_proto_enumTypes[2]
+}
+
+func (x Balancing) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Balancing.Descriptor instead.
+func (Balancing) EnumDescriptor() ([]byte, []int) {
+	return file_proxy_options_proto_rawDescGZIP(), []int{2}
+}
+
+// Aggregation describes how the responses of the targets get merged into a
+// single response.
+type Aggregation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the repeated field of the response collecting the messages of
+	// each target, defaults to the only repeated message field of the response.
+	Field string `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	// Name of the field of the collected messages set to the metadata of the
+	// target, defaults to the only field holding a message with a string
+	// hostname field.
+	MetadataField string `protobuf:"bytes,2,opt,name=metadata_field,json=metadataField,proto3" json:"metadata_field,omitempty"`
+	// Report each failed target as a message of the repeated field, with the
+	// error and code fields of its node metadata set, instead of failing the
+	// whole call.
+	InlineErrors  *bool `protobuf:"varint,3,opt,name=inline_errors,json=inlineErrors,proto3,oneof" json:"inline_errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Aggregation) Reset() {
+	*x = Aggregation{}
+	mi := &file_proxy_options_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Aggregation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Aggregation) ProtoMessage() {}
+
+func (x *Aggregation) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_options_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Aggregation.ProtoReflect.Descriptor instead.
+func (*Aggregation) Descriptor() ([]byte, []int) {
+	return file_proxy_options_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Aggregation) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *Aggregation) GetMetadataField() string {
+	if x != nil {
+		return x.MetadataField
+	}
+	return ""
+}
+
+func (x *Aggregation) GetInlineErrors() bool {
+	if x != nil && x.InlineErrors != nil {
+		return *x.InlineErrors
+	}
+	return false
+}
+
+// Execution controls how the calls of a fanned out request are spread over
+// the targets. The requests can override it with the proxy-max-concurrency,
+// proxy-batch-size, proxy-stop-on-error and proxy-target-timeout metadata
+// keys.
+type Execution struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Maximum number of targets called at once, unlimited by default.
+	MaxConcurrency *uint32 `protobuf:"varint,1,opt,name=max_concurrency,json=maxConcurrency,proto3,oneof" json:"max_concurrency,omitempty"`
+	// Number of targets of each wave of a rolling request, each wave starting
+	// once the previous one is done. All the targets are called in a single
+	// wave by default.
+	BatchSize *uint32 `protobuf:"varint,2,opt,name=batch_size,json=batchSize,proto3,oneof" json:"batch_size,omitempty"`
+	// Skip the waves left once a target fails.
+	StopOnError *bool `protobuf:"varint,3,opt,name=stop_on_error,json=stopOnError,proto3,oneof" json:"stop_on_error,omitempty"`
+	// Time given to each target to answer, the targets which don't answer in
+	// time fail with DEADLINE_EXCEEDED. Only the deadline of the request
+	// applies by default.
+	TargetTimeout *durationpb.Duration `protobuf:"bytes,4,opt,name=target_timeout,json=targetTimeout,proto3" json:"target_timeout,omitempty"`
+	// Targets answering the request, batch_size and stop_on_error only apply
+	// to ALL.
+	Dispatch *Dispatch `protobuf:"varint,5,opt,name=dispatch,proto3,enum=proxy.Dispatch,oneof" json:"dispatch,omitempty"`
+	// Target picked by the ONE_OF dispatch.
+	Balancing     *Balancing `protobuf:"varint,6,opt,name=balancing,proto3,enum=proxy.Balancing,oneof" json:"balancing,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Execution) Reset() {
+	*x = Execution{}
+	mi := &file_proxy_options_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Execution) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Execution) ProtoMessage() {}
+
+func (x *Execution) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_options_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Execution.ProtoReflect.Descriptor instead.
+func (*Execution) Descriptor() ([]byte, []int) {
+	return file_proxy_options_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Execution) GetMaxConcurrency() uint32 {
+	if x != nil && x.MaxConcurrency != nil {
+		return *x.MaxConcurrency
+	}
+	return 0
+}
+
+func (x *Execution) GetBatchSize() uint32 {
+	if x != nil && x.BatchSize != nil {
+		return *x.BatchSize
+	}
+	return 0
+}
+
+func (x *Execution) GetStopOnError() bool {
+	if x != nil && x.StopOnError != nil {
+		return *x.StopOnError
+	}
+	return false
+}
+
+func (x *Execution) GetTargetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.TargetTimeout
+	}
+	return nil
+}
+
+func (x *Execution) GetDispatch() Dispatch {
+	if x != nil && x.Dispatch != nil {
+		return *x.Dispatch
+	}
+	return Dispatch_ALL
+}
+
+func (x *Execution) GetBalancing() Balancing {
+	if x != nil && x.Balancing != nil {
+		return *x.Balancing
+	}
+	return Balancing_ROUND_ROBIN
+}
+
+// CallOptions are applied to the calls to the targets, on top of the call
+// options the proxy gets called with.
+type CallOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Maximum size of the responses of the targets, in bytes.
+	MaxRecvMsgSize *uint32 `protobuf:"varint,1,opt,name=max_recv_msg_size,json=maxRecvMsgSize,proto3,oneof" json:"max_recv_msg_size,omitempty"`
+	// Maximum size of the requests to the targets, in bytes.
+	MaxSendMsgSize *uint32 `protobuf:"varint,2,opt,name=max_send_msg_size,json=maxSendMsgSize,proto3,oneof" json:"max_send_msg_size,omitempty"`
+	// Wait for the connections to the targets to be ready instead of failing
+	// right away.
+	WaitForReady *bool `protobuf:"varint,3,opt,name=wait_for_ready,json=waitForReady,proto3,oneof" json:"wait_for_ready,omitempty"`
+	// Name of the compressor of the requests, e.g. gzip. The compressor must
+	// be registered with gRPC.
+	Compressor    *string `protobuf:"bytes,4,opt,name=compressor,proto3,oneof" json:"compressor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CallOptions) Reset() {
+	*x = CallOptions{}
+	mi := &file_proxy_options_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CallOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallOptions) ProtoMessage() {}
+
+func (x *CallOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_options_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallOptions.ProtoReflect.Descriptor instead.
+func (*CallOptions) Descriptor() ([]byte, []int) {
+	return file_proxy_options_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CallOptions) GetMaxRecvMsgSize() uint32 {
+	if x != nil && x.MaxRecvMsgSize != nil {
+		return *x.MaxRecvMsgSize
+	}
+	return 0
+}
+
+func (x *CallOptions) GetMaxSendMsgSize() uint32 {
+	if x != nil && x.MaxSendMsgSize != nil {
+		return *x.MaxSendMsgSize
+	}
+	return 0
+}
+
+func (x *CallOptions) GetWaitForReady() bool {
+	if x != nil && x.WaitForReady != nil {
+		return *x.WaitForReady
+	}
+	return false
+}
+
+func (x *CallOptions) GetCompressor() string {
+	if x != nil && x.Compressor != nil {
+		return *x.Compressor
+	}
+	return ""
+}
+
+// ServiceOptions configures the proxy for all the methods of a service.
+type ServiceOptions struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Mode        *Mode                  `protobuf:"varint,1,opt,name=mode,proto3,enum=proxy.Mode,oneof" json:"mode,omitempty"`
+	Aggregation *Aggregation           `protobuf:"bytes,2,opt,name=aggregation,proto3" json:"aggregation,omitempty"`
+	Execution   *Execution             `protobuf:"bytes,3,opt,name=execution,proto3" json:"execution,omitempty"`
+	// Roles allowed to call the methods, the caller needs one of them among
+	// the organizations of its certificate. Anyone may call them by default.
+	Roles         []string     `protobuf:"bytes,4,rep,name=roles,proto3" json:"roles,omitempty"`
+	Call          *CallOptions `protobuf:"bytes,5,opt,name=call,proto3" json:"call,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServiceOptions) Reset() {
+	*x = ServiceOptions{}
+	mi := &file_proxy_options_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceOptions) ProtoMessage() {}
+
+func (x *ServiceOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_options_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceOptions.ProtoReflect.Descriptor instead.
+func (*ServiceOptions) Descriptor() ([]byte, []int) {
+	return file_proxy_options_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ServiceOptions) GetMode() Mode {
+	if x != nil && x.Mode != nil {
+		return *x.Mode
+	}
+	return Mode_FANOUT
+}
+
+func (x *ServiceOptions) GetAggregation() *Aggregation {
+	if x != nil {
+		return x.Aggregation
+	}
+	return nil
+}
+
+func (x *ServiceOptions) GetExecution() *Execution {
+	if x != nil {
+		return x.Execution
+	}
+	return nil
+}
+
+func (x *ServiceOptions) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *ServiceOptions) GetCall() *CallOptions {
+	if x != nil {
+		return x.Call
+	}
+	return nil
+}
+
+// MethodOptions configures the proxy for a single method, overriding the
+// options of its service.
+type MethodOptions struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Mode        *Mode                  `protobuf:"varint,1,opt,name=mode,proto3,enum=proxy.Mode,oneof" json:"mode,omitempty"`
+	Aggregation *Aggregation           `protobuf:"bytes,2,opt,name=aggregation,proto3" json:"aggregation,omitempty"`
+	Execution   *Execution             `protobuf:"bytes,3,opt,name=execution,proto3" json:"execution,omitempty"`
+	// Roles allowed to call the method, replacing the roles of the service.
+	Roles         []string     `protobuf:"bytes,4,rep,name=roles,proto3" json:"roles,omitempty"`
+	Call          *CallOptions `protobuf:"bytes,5,opt,name=call,proto3" json:"call,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MethodOptions) Reset() {
+	*x = MethodOptions{}
+	mi := &file_proxy_options_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MethodOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MethodOptions) ProtoMessage() {}
+
+func (x *MethodOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_proxy_options_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MethodOptions.ProtoReflect.Descriptor instead.
+func (*MethodOptions) Descriptor() ([]byte, []int) {
+	return file_proxy_options_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MethodOptions) GetMode() Mode {
+	if x != nil && x.Mode != nil {
+		return *x.Mode
+	}
+	return Mode_FANOUT
+}
+
+func (x *MethodOptions) GetAggregation() *Aggregation {
+	if x != nil {
+		return x.Aggregation
+	}
+	return nil
+}
+
+func (x *MethodOptions) GetExecution() *Execution {
+	if x != nil {
+		return x.Execution
+	}
+	return nil
+}
+
+func (x *MethodOptions) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *MethodOptions) GetCall() *CallOptions {
+	if x != nil {
+		return x.Call
+	}
+	return nil
+}
+
+var file_proxy_options_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.ServiceOptions)(nil),
+		ExtensionType: (*ServiceOptions)(nil),
+		Field:         51200,
+		Name:          "proxy.service",
+		Tag:           "bytes,51200,opt,name=service",
+		Filename:      "proxy/options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*MethodOptions)(nil),
+		Field:         51200,
+		Name:          "proxy.method",
+		Tag:           "bytes,51200,opt,name=method",
+		Filename:      "proxy/options.proto",
+	},
+}
+
+// Extension fields to descriptorpb.ServiceOptions.
+var (
+	// optional proxy.ServiceOptions service = 51200;
+	E_Service = &file_proxy_options_proto_extTypes[0]
+)
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// optional proxy.MethodOptions method = 51200;
+	E_Method = &file_proxy_options_proto_extTypes[1]
+)
+
+var File_proxy_options_proto protoreflect.FileDescriptor
+
+const file_proxy_options_proto_rawDesc = "" +
+	"\n" +
+	"\x13proxy/options.proto\x12\x05proxy\x1a google/protobuf/descriptor.proto\x1a\x1egoogle/protobuf/duration.proto\"\x86\x01\n" +
+	"\vAggregation\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12%\n" +
+	"\x0emetadata_field\x18\x02 \x01(\tR\rmetadataField\x12(\n" +
+	"\rinline_errors\x18\x03 \x01(\bH\x00R\finlineErrors\x88\x01\x01B\x10\n" +
+	"\x0e_inline_errors\"\xff\x02\n" +
+	"\tExecution\x12,\n" +
+	"\x0fmax_concurrency\x18\x01 \x01(\rH\x00R\x0emaxConcurrency\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"batch_size\x18\x02 \x01(\rH\x01R\tbatchSize\x88\x01\x01\x12'\n" +
+	"\rstop_on_error\x18\x03 \x01(\bH\x02R\vstopOnError\x88\x01\x01\x12@\n" +
+	"\x0etarget_timeout\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\rtargetTimeout\x120\n" +
+	"\bdispatch\x18\x05 \x01(\x0e2\x0f.proxy.DispatchH\x03R\bdispatch\x88\x01\x01\x123\n" +
+	"\tbalancing\x18\x06 \x01(\x0e2\x10.proxy.BalancingH\x04R\tbalancing\x88\x01\x01B\x12\n" +
+	"\x10_max_concurrencyB\r\n" +
+	"\v_batch_sizeB\x10\n" +
+	"\x0e_stop_on_errorB\v\n" +
+	"\t_dispatchB\f\n" +
+	"\n" +
+	"_balancing\"\x8b\x02\n" +
+	"\vCallOptions\x12.\n" +
+	"\x11max_recv_msg_size\x18\x01 \x01(\rH\x00R\x0emaxRecvMsgSize\x88\x01\x01\x12.\n" +
+	"\x11max_send_msg_size\x18\x02 \x01(\rH\x01R\x0emaxSendMsgSize\x88\x01\x01\x12)\n" +
+	"\x0ewait_for_ready\x18\x03 \x01(\bH\x02R\fwaitForReady\x88\x01\x01\x12#\n" +
+	"\n" +
+	"compressor\x18\x04 \x01(\tH\x03R\n" +
+	"compressor\x88\x01\x01B\x14\n" +
+	"\x12_max_recv_msg_sizeB\x14\n" +
+	"\x12_max_send_msg_sizeB\x11\n" +
+	"\x0f_wait_for_readyB\r\n" +
+	"\v_compressor\"\xe3\x01\n" +
+	"\x0eServiceOptions\x12$\n" +
+	"\x04mode\x18\x01 \x01(\x0e2\v.proxy.ModeH\x00R\x04mode\x88\x01\x01\x124\n" +
+	"\vaggregation\x18\x02 \x01(\v2\x12.proxy.AggregationR\vaggregation\x12.\n" +
+	"\texecution\x18\x03 \x01(\v2\x10.proxy.ExecutionR\texecution\x12\x14\n" +
+	"\x05roles\x18\x04 \x03(\tR\x05roles\x12&\n" +
+	"\x04call\x18\x05 \x01(\v2\x12.proxy.CallOptionsR\x04callB\a\n" +
+	"\x05_mode\"\xe2\x01\n" +
+	"\rMethodOptions\x12$\n" +
+	"\x04mode\x18\x01 \x01(\x0e2\v.proxy.ModeH\x00R\x04mode\x88\x01\x01\x124\n" +
+	"\vaggregation\x18\x02 \x01(\v2\x12.proxy.AggregationR\vaggregation\x12.\n" +
+	"\texecution\x18\x03 \x01(\v2\x10.proxy.ExecutionR\texecution\x12\x14\n" +
+	"\x05roles\x18\x04 \x03(\tR\x05roles\x12&\n" +
+	"\x04call\x18\x05 \x01(\v2\x12.proxy.CallOptionsR\x04callB\a\n" +
+	"\x05_mode*8\n" +
+	"\x04Mode\x12\n" +
+	"\n" +
+	"\x06FANOUT\x10\x00\x12\n" +
+	"\n" +
+	"\x06SINGLE\x10\x01\x12\x0e\n" +
+	"\n" +
+	"LOCAL_ONLY\x10\x02\x12\b\n" +
+	"\x04SKIP\x10\x03*4\n" +
+	"\bDispatch\x12\a\n" +
+	"\x03ALL\x10\x00\x12\a\n" +
+	"\x03ANY\x10\x01\x12\n" +
+	"\n" +
+	"\x06QUORUM\x10\x02\x12\n" +
+	"\n" +
+	"\x06ONE_OF\x10\x03*.\n" +
+	"\tBalancing\x12\x0f\n" +
+	"\vROUND_ROBIN\x10\x00\x12\x10\n" +
+	"\fLEAST_LOADED\x10\x01:R\n" +
+	"\aservice\x12\x1f.google.protobuf.ServiceOptions\x18\x80\x90\x03 \x01(\v2\x15.proxy.ServiceOptionsR\aservice:N\n" +
+	"\x06method\x12\x1e.google.protobuf.MethodOptions\x18\x80\x90\x03 \x01(\v2\x14.proxy.MethodOptionsR\x06methodB1Z/github.com/talos-systems/protoc-gen-proxy/proxyb\x06proto3"
+
+var (
+	file_proxy_options_proto_rawDescOnce sync.Once
+	file_proxy_options_proto_rawDescData []byte
+)
+
+func file_proxy_options_proto_rawDescGZIP() []byte {
+	file_proxy_options_proto_rawDescOnce.Do(func() {
+		file_proxy_options_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proxy_options_proto_rawDesc), len(file_proxy_options_proto_rawDesc)))
+	})
+	return file_proxy_options_proto_rawDescData
+}
+
+var file_proxy_options_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_proxy_options_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_proxy_options_proto_goTypes = []any{
+	(Mode)(0),                           // 0: proxy.Mode
+	(Dispatch)(0),                       // 1: proxy.Dispatch
+	(Balancing)(0),                      // 2: proxy.Balancing
+	(*Aggregation)(nil),                 // 3: proxy.Aggregation
+	(*Execution)(nil),                   // 4: proxy.Execution
+	(*CallOptions)(nil),                 // 5: proxy.CallOptions
+	(*ServiceOptions)(nil),              // 6: proxy.ServiceOptions
+	(*MethodOptions)(nil),               // 7: proxy.MethodOptions
+	(*durationpb.Duration)(nil),         // 8: google.protobuf.Duration
+	(*descriptorpb.ServiceOptions)(nil), // 9: google.protobuf.ServiceOptions
+	(*descriptorpb.MethodOptions)(nil),  // 10: google.protobuf.MethodOptions
+}
+var file_proxy_options_proto_depIdxs = []int32{
+	8,  // 0: proxy.Execution.target_timeout:type_name -> google.protobuf.Duration
+	1,  // 1: proxy.Execution.dispatch:type_name -> proxy.Dispatch
+	2,  // 2: proxy.Execution.balancing:type_name -> proxy.Balancing
+	0,  // 3: proxy.ServiceOptions.mode:type_name -> proxy.Mode
+	3,  // 4: proxy.ServiceOptions.aggregation:type_name -> proxy.Aggregation
+	4,  // 5: proxy.ServiceOptions.execution:type_name -> proxy.Execution
+	5,  // 6: proxy.ServiceOptions.call:type_name -> proxy.CallOptions
+	0,  // 7: proxy.MethodOptions.mode:type_name -> proxy.Mode
+	3,  // 8: proxy.MethodOptions.aggregation:type_name -> proxy.Aggregation
+	4,  // 9: proxy.MethodOptions.execution:type_name -> proxy.Execution
+	5,  // 10: proxy.MethodOptions.call:type_name -> proxy.CallOptions
+	9,  // 11: proxy.service:extendee -> google.protobuf.ServiceOptions
+	10, // 12: proxy.method:extendee -> google.protobuf.MethodOptions
+	6,  // 13: proxy.service:type_name -> proxy.ServiceOptions
+	7,  // 14: proxy.method:type_name -> proxy.MethodOptions
+	15, // [15:15] is the sub-list for method output_type
+	15, // [15:15] is the sub-list for method input_type
+	13, // [13:15] is the sub-list for extension type_name
+	11, // [11:13] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_proxy_options_proto_init() }
+func file_proxy_options_proto_init() {
+	if File_proxy_options_proto != nil {
+		return
+	}
+	file_proxy_options_proto_msgTypes[0].OneofWrappers = []any{}
+	file_proxy_options_proto_msgTypes[1].OneofWrappers = []any{}
+	file_proxy_options_proto_msgTypes[2].OneofWrappers = []any{}
+	file_proxy_options_proto_msgTypes[3].OneofWrappers = []any{}
+	file_proxy_options_proto_msgTypes[4].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proxy_options_proto_rawDesc), len(file_proxy_options_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   5,
+			NumExtensions: 2,
+			NumServices:   0,
+		},
+		GoTypes:           file_proxy_options_proto_goTypes,
+		DependencyIndexes: file_proxy_options_proto_depIdxs,
+		EnumInfos:         file_proxy_options_proto_enumTypes,
+		MessageInfos:      file_proxy_options_proto_msgTypes,
+		ExtensionInfos:    file_proxy_options_proto_extTypes,
+	}.Build()
+	File_proxy_options_proto = out.File
+	file_proxy_options_proto_goTypes = nil
+	file_proxy_options_proto_depIdxs = nil
+}